@@ -0,0 +1,164 @@
+package mykubota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// GetEquipmentBatchOptions configures bulk equipment downloads
+type GetEquipmentBatchOptions struct {
+	// Concurrency limits the number of parallel requests. Defaults to 4
+	Concurrency int
+	// RequestsPerSecond limits the request rate across all workers. Zero doesn't limit the rate
+	RequestsPerSecond float64
+	// MaxRetries limits retries of requests which were rate limited or rejected as unavailable.
+	// Defaults to 3, negative values disable retries
+	MaxRetries int
+	// Progress is called once per ID after its request finished. Calls are never concurrent
+	Progress func(GetEquipmentBatchProgress)
+}
+
+// GetEquipmentBatchProgress describes the outcome of a single ID during a bulk download
+type GetEquipmentBatchProgress struct {
+	ID    string
+	Err   error
+	Done  int
+	Total int
+}
+
+// EquipmentBatchResult contains all equipment which was loaded, and errors for all other IDs
+type EquipmentBatchResult struct {
+	Equipment map[string]*Equipment
+	Errors    map[string]error
+}
+
+// GetEquipmentBatch fetches the details of many equipment with bounded parallelism.
+// Responses with 429 Too Many Requests or 503 Service Unavailable pause all workers for the
+// Retry-After delay, or an exponential backoff, and are retried.
+// Failing IDs are reported in the result instead of aborting the download.
+// If ctx is cancelled, the partial result is returned alongside the context error
+func (s *Session) GetEquipmentBatch(ctx context.Context, ids []string, opts GetEquipmentBatchOptions) (*EquipmentBatchResult, error) {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 4
+	}
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	limiter := &rateLimiter{}
+	if opts.RequestsPerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / opts.RequestsPerSecond)
+	}
+
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	res := &EquipmentBatchResult{
+		Equipment: map[string]*Equipment{},
+		Errors:    map[string]error{},
+	}
+	mu := sync.Mutex{}
+	done := 0
+
+	g := errgroup.Group{}
+	g.SetLimit(concurrency)
+	for _, id := range unique {
+		if ctx.Err() != nil {
+			break
+		}
+		id := id
+		g.Go(func() error {
+			var eq *Equipment
+			var err error
+			for attempt := 0; ; attempt++ {
+				if err = limiter.wait(ctx); err != nil {
+					break
+				}
+				eq, err = s.GetEquipment(ctx, id)
+				statusErr := &StatusError{}
+				if attempt >= maxRetries || !errors.As(err, &statusErr) ||
+					(statusErr.StatusCode != http.StatusTooManyRequests && statusErr.StatusCode != http.StatusServiceUnavailable) {
+					break
+				}
+				delay := statusErr.RetryAfter
+				if delay == 0 {
+					delay = time.Duration(1<<attempt) * time.Second
+				}
+				limiter.pause(delay)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				res.Errors[id] = err
+			} else {
+				res.Equipment[id] = eq
+			}
+			done++
+			if opts.Progress != nil {
+				opts.Progress(GetEquipmentBatchProgress{
+					ID:    id,
+					Err:   err,
+					Done:  done,
+					Total: len(unique),
+				})
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return res, ctx.Err()
+}
+
+// rateLimiter spaces requests of concurrent workers by interval, and pauses all of them
+// when the API asks to slow down
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next request may be sent
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return ctx.Err()
+}
+
+// pause delays all requests by at least d
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at := time.Now().Add(d); at.After(l.next) {
+		l.next = at
+	}
+}
@@ -0,0 +1,204 @@
+// Package cassette records HTTP interactions into sanitized files and replays them,
+// so integration tests run without network access or credentials
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode selects whether a Recorder talks to the real service
+type Mode int
+
+const (
+	// Replay serves responses from the cassette file
+	Replay Mode = iota
+	// Record forwards requests and stores sanitized interactions in the cassette file
+	Record
+)
+
+// Request is a recorded HTTP request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a request and the response it received
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file format of recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying interactions
+type Recorder struct {
+	Path string
+	Mode Mode
+	// Transport sends requests in Record mode. Defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// Scrub sanitizes interactions before they are stored. Defaults to Scrub
+	Scrub func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// New returns a recorder for the cassette at path. In Replay mode the cassette must exist,
+// otherwise an error wrapping os.ErrNotExist is returned
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{Path: path, Mode: mode}
+	if mode == Record {
+		return r, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, &r.cassette); err != nil {
+		return nil, fmt.Errorf("unable to decode cassette %s: %w", path, err)
+	}
+	r.replayed = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := []byte{}
+	if req.Body != nil {
+		bs, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = bs
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if r.Mode == Record {
+		return r.record(req, body)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(respBody),
+		},
+	}
+	scrub := r.Scrub
+	if scrub == nil {
+		scrub = Scrub
+	}
+	scrub(&i)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	return resp, nil
+}
+
+// replay returns the first unused interaction matching method and URL of the request.
+// Once all matching interactions were used the last one is repeated
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	scrub := r.Scrub
+	if scrub == nil {
+		scrub = Scrub
+	}
+	// requests are matched in their sanitized form, as the cassette only contains those
+	probe := Interaction{Request: Request{Method: req.Method, URL: req.URL.String()}}
+	scrub(&probe)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	match := -1
+	for idx, i := range r.cassette.Interactions {
+		if i.Request.Method != probe.Request.Method || i.Request.URL != probe.Request.URL {
+			continue
+		}
+		match = idx
+		if !r.replayed[idx] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("cassette %s has no interaction for %s %s", r.Path, req.Method, probe.Request.URL)
+	}
+	r.replayed[match] = true
+
+	i := r.cassette.Interactions[match]
+	header := i.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(i.Response.Body))),
+		ContentLength: int64(len(i.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes recorded interactions to the cassette file. It does nothing in Replay mode
+func (r *Recorder) Save() error {
+	if r.Mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	bs, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, append(bs, '\n'), 0644)
+}
@@ -0,0 +1,120 @@
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/oauth/token":
+			w.Header().Set("Set-Cookie", "session=secret")
+			fmt.Fprint(w, `{"access_token":"secret-access","refresh_token":"secret-refresh","expires_in":3600}`)
+		case "/api/user/equipment":
+			fmt.Fprintf(w, `[{"id":"eq-1","pin":"KBCDZ26CEN3K12345","serial":"12345","nickName":"owned by jane@doe.com","telematics":{"location":{"latitude":49.28,"longitude":-123.12}},"call":%d}]`, n)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "equipment.json")
+	rec, err := New(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec}
+	form := url.Values{"grant_type": {"password"}, "username": {"jane@doe.com"}, "password": {"hunter2"}}
+	if _, err := client.PostForm(srv.URL+"/oauth/token", form); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", srv.URL+"/api/user/equipment", nil)
+		req.Header.Set("Authorization", "Bearer secret-access")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, _ := io.ReadAll(resp.Body)
+		if !strings.Contains(string(bs), "KBCDZ26CEN3K12345") {
+			t.Fatalf("expected recording to pass through the real response, got %s", bs)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-access", "secret-refresh", "session=secret", "jane@doe.com", "hunter2", "KBCDZ26CEN3K12345", `"12345"`, "49.28", "-123.12"} {
+		if strings.Contains(string(bs), secret) {
+			t.Errorf("expected cassette to not contain %q", secret)
+		}
+	}
+
+	replay, err := New(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replay}
+	for i := 1; i <= 3; i++ {
+		resp, err := client.Get("http://example.com/api/user/equipment")
+		if err == nil {
+			t.Fatalf("expected host to be part of the match, got %d", resp.StatusCode)
+		}
+		resp, err = client.Get(srv.URL + "/api/user/equipment")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, _ := io.ReadAll(resp.Body)
+		// interactions are replayed in order, repeating the last one when exhausted
+		call := i + 1
+		if i == 3 {
+			call = 3
+		}
+		if !strings.Contains(string(bs), fmt.Sprintf(`"call":%d`, call)) {
+			t.Fatalf("unexpected replayed body %s for request %d", bs, i)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("expected replay to not hit the server, got %d calls", calls)
+	}
+}
+
+func TestNew_MissingCassette(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), Replay); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected missing cassette error, got %v", err)
+	}
+}
+
+func TestScrub_StablePlaceholders(t *testing.T) {
+	t.Parallel()
+
+	i := Interaction{
+		Request:  Request{Method: "GET", URL: "https://app.mykubota.com/api/models?partialModel=kx0&serial=30123"},
+		Response: Response{Body: `{"models":[{"pinOrSerial":"30123"}]}`},
+	}
+	Scrub(&i)
+	want := placeholder("30123")
+	if !strings.Contains(i.Request.URL, "serial="+want) || !strings.Contains(i.Response.Body, `"pinOrSerial":"`+want+`"`) {
+		t.Fatalf("expected serial to be replaced by %s, got %+v", want, i)
+	}
+}
@@ -0,0 +1,160 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in scrubbed interactions
+const Redacted = "REDACTED"
+
+// Coordinates replacing recorded locations
+const (
+	ScrubbedLatitude  = 43.6532
+	ScrubbedLongitude = -79.3832
+)
+
+var (
+	// sensitiveHeaders are dropped from recorded interactions
+	sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Content-Length"}
+	// secretFields are replaced by Redacted in JSON and form bodies
+	secretFields = map[string]bool{
+		"access_token":  true,
+		"refresh_token": true,
+		"id_token":      true,
+		"password":      true,
+		"username":      true,
+		"phone_number":  true,
+	}
+	// identifierFields are replaced by stable placeholders in JSON bodies and query strings
+	identifierFields = map[string]bool{
+		"pin":         true,
+		"serial":      true,
+		"pinOrSerial": true,
+	}
+	coordinateFields = map[string]float64{
+		"latitude":  ScrubbedLatitude,
+		"longitude": ScrubbedLongitude,
+	}
+
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// pinPattern matches 17 character product identification numbers, which use VIN characters
+	pinPattern = regexp.MustCompile(`\b[A-HJ-NPR-Z0-9]{17}\b`)
+)
+
+// Scrub removes credentials, email addresses, PINs, serial numbers and GPS coordinates.
+// Identifiers are replaced by placeholders derived from their value, so equal identifiers
+// stay equal across interactions
+func Scrub(i *Interaction) {
+	for _, h := range sensitiveHeaders {
+		i.Request.Header.Del(h)
+		i.Response.Header.Del(h)
+	}
+	i.Request.URL = scrubURL(i.Request.URL)
+	i.Request.Body = scrubBody(i.Request.Body)
+	i.Response.Body = scrubBody(i.Response.Body)
+}
+
+func scrubURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.User = nil
+	u.Path = scrubString(u.Path)
+	u.RawPath = ""
+	if u.RawQuery != "" {
+		query := u.Query()
+		for k, vs := range query {
+			for idx, v := range vs {
+				vs[idx] = scrubField(k, v)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// scrubBody sanitizes JSON and form encoded bodies. Other bodies only have emails and PINs replaced
+func scrubBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if trimmed == "" {
+		return body
+	}
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		dec := json.NewDecoder(strings.NewReader(trimmed))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err == nil {
+			buf := bytes.Buffer{}
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(scrubJSON("", v)); err == nil {
+				return strings.TrimSuffix(buf.String(), "\n")
+			}
+		}
+	}
+	if form, err := url.ParseQuery(trimmed); err == nil && strings.Contains(trimmed, "=") && !strings.ContainsAny(trimmed, " \n") {
+		for k, vs := range form {
+			for idx, v := range vs {
+				vs[idx] = scrubField(k, v)
+			}
+		}
+		return form.Encode()
+	}
+	return scrubString(body)
+}
+
+func scrubJSON(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = scrubJSON(k, child)
+		}
+		return v
+	case []any:
+		for idx, child := range v {
+			v[idx] = scrubJSON(key, child)
+		}
+		return v
+	case json.Number:
+		if replacement, ok := coordinateFields[key]; ok {
+			return replacement
+		}
+		return v
+	case string:
+		return scrubField(key, v)
+	}
+	return v
+}
+
+func scrubField(key, value string) string {
+	switch {
+	case value == "":
+		return value
+	case secretFields[key]:
+		return Redacted
+	case identifierFields[key]:
+		return placeholder(value)
+	}
+	return scrubString(value)
+}
+
+func scrubString(s string) string {
+	s = emailPattern.ReplaceAllString(s, "user@example.com")
+	return pinPattern.ReplaceAllStringFunc(s, placeholder)
+}
+
+// placeholder derives a stable replacement of the same length from an identifier
+func placeholder(v string) string {
+	sum := fmt.Sprintf("%X", sha256.Sum256([]byte(v)))
+	if len(v) > len(sum) {
+		return sum
+	}
+	return sum[:len(v)]
+}
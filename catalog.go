@@ -0,0 +1,232 @@
+package mykubota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrCatalogNotCached is returned by an offline CatalogCache without a snapshot for the requested locale
+var ErrCatalogNotCached = errors.New("model catalog is not cached")
+
+// CatalogSnapshot is a point in time copy of the model catalog for a single locale and endpoint
+type CatalogSnapshot struct {
+	// Endpoint the catalog was downloaded from. Empty in snapshots written before it was recorded,
+	// which were downloaded from AppEndpoint
+	Endpoint     string     `json:"endpoint,omitempty"`
+	Locale       string     `json:"locale"`
+	FetchedAt    time.Time  `json:"fetchedAt"`
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"lastModified,omitempty"`
+	Categories   []Category `json:"categories"`
+	Models       []Model    `json:"models"`
+}
+
+// CatalogCache keeps the model catalog in memory and on disk, so ListModels, ListCategories
+// and GetModelTree only download it once per TTL.
+// Expired snapshots are revalidated using ETag/ Last-Modified headers.
+// Snapshots are kept per endpoint and locale, and each is downloaded by one client at a time
+type CatalogCache struct {
+	// Dir persists snapshots across processes. Snapshots are kept in memory only if empty
+	Dir string
+	// TTL defines how long a snapshot is served before it is revalidated
+	TTL time.Duration
+	// Offline serves cached snapshots regardless of their age and never contacts the API
+	Offline bool
+
+	// mu guards snapshots and locks, but isn't held while downloading
+	mu        sync.Mutex
+	snapshots map[catalogKey]*CatalogSnapshot
+	locks     map[catalogKey]*sync.Mutex
+}
+
+// catalogKey identifies the catalog of a locale served by an endpoint
+type catalogKey struct {
+	endpoint string
+	locale   string
+}
+
+// WithCatalogCache serves the model catalog from the given cache
+func WithCatalogCache(cache *CatalogCache) Option {
+	return func(c *Client) {
+		c.catalog = cache
+	}
+}
+
+func (cc *CatalogCache) load(ctx context.Context, c *Client) (*CatalogSnapshot, error) {
+	key := catalogKey{endpoint: c.endpoint, locale: c.locale}
+	lock := cc.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	snapshot, err := cc.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	if cc.Offline {
+		if snapshot == nil {
+			return nil, fmt.Errorf("%w for locale %s", ErrCatalogNotCached, c.locale)
+		}
+		return snapshot, nil
+	}
+	if snapshot != nil && time.Since(snapshot.FetchedAt) < cc.TTL {
+		return snapshot, nil
+	}
+
+	snapshot, err = c.fetchCatalog(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := cc.store(key, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// lock returns the lock serializing downloads of the catalog identified by key
+func (cc *CatalogCache) lock(key catalogKey) *sync.Mutex {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.locks == nil {
+		cc.locks = map[catalogKey]*sync.Mutex{}
+	}
+	if _, ok := cc.locks[key]; !ok {
+		cc.locks[key] = &sync.Mutex{}
+	}
+	return cc.locks[key]
+}
+
+// path keeps the file name of the catalog served by AppEndpoint, and adds a hash of other endpoints
+func (cc *CatalogCache) path(key catalogKey) string {
+	if key.endpoint == AppEndpoint {
+		return filepath.Join(cc.Dir, fmt.Sprintf("catalog-%s.json", key.locale))
+	}
+	hash := sha256.Sum256([]byte(key.endpoint))
+	return filepath.Join(cc.Dir, fmt.Sprintf("catalog-%s-%x.json", key.locale, hash[:4]))
+}
+
+func (cc *CatalogCache) lookup(key catalogKey) (*CatalogSnapshot, error) {
+	cc.mu.Lock()
+	snapshot, ok := cc.snapshots[key]
+	cc.mu.Unlock()
+	if ok {
+		return snapshot, nil
+	}
+	if cc.Dir == "" {
+		return nil, nil
+	}
+	bs, err := os.ReadFile(cc.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot = &CatalogSnapshot{}
+	if err := json.Unmarshal(bs, snapshot); err != nil {
+		return nil, fmt.Errorf("unable to decode cached catalog %s: %w", cc.path(key), err)
+	}
+	if endpoint := snapshot.Endpoint; endpoint != key.endpoint && !(endpoint == "" && key.endpoint == AppEndpoint) {
+		return nil, nil
+	}
+	cc.remember(key, snapshot)
+	return snapshot, nil
+}
+
+func (cc *CatalogCache) remember(key catalogKey, snapshot *CatalogSnapshot) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.snapshots == nil {
+		cc.snapshots = map[catalogKey]*CatalogSnapshot{}
+	}
+	cc.snapshots[key] = snapshot
+}
+
+func (cc *CatalogCache) store(key catalogKey, snapshot *CatalogSnapshot) error {
+	cc.remember(key, snapshot)
+	if cc.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(cc.Dir, 0755); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so concurrent readers never observe a partial snapshot
+	tmp, err := os.CreateTemp(cc.Dir, "catalog-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cc.path(key))
+}
+
+// fetchCatalog downloads the model catalog. If cached is set the request is conditional,
+// and cached is returned with an updated timestamp if the catalog didn't change
+func (c *Client) fetchCatalog(ctx context.Context, cached *CatalogSnapshot) (*CatalogSnapshot, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/models", c.endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	type modelsResponse struct {
+		Categories []Category `json:"categories"`
+		Models     []Model    `json:"models"`
+	}
+	snapshot := CatalogSnapshot{Endpoint: c.endpoint, Locale: c.locale}
+	err = c.do(req.WithContext(ctx), []int{http.StatusOK, http.StatusNotModified}, func(resp *http.Response) error {
+		snapshot.ETag = resp.Header.Get("ETag")
+		snapshot.LastModified = resp.Header.Get("Last-Modified")
+		if resp.StatusCode == http.StatusNotModified {
+			if cached == nil {
+				return fmt.Errorf("received unexpected not modified response for unconditional request")
+			}
+			snapshot.Categories = cached.Categories
+			snapshot.Models = cached.Models
+			if snapshot.ETag == "" {
+				snapshot.ETag = cached.ETag
+			}
+			if snapshot.LastModified == "" {
+				snapshot.LastModified = cached.LastModified
+			}
+			return nil
+		}
+		res := modelsResponse{}
+		if err := decodeJSON(resp, &res); err != nil {
+			return err
+		}
+		snapshot.Categories = res.Categories
+		snapshot.Models = res.Models
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	snapshot.FetchedAt = time.Now()
+	return &snapshot, nil
+}
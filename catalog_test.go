@@ -0,0 +1,159 @@
+package mykubota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCatalogServer(t *testing.T, etag string) (*httptest.Server, *int32, *int32) {
+	var requests, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/models" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"categories":[{"id":1,"name":"Construction"}],"models":[{"model":"KX040-4","categoryId":1}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &notModified
+}
+
+func TestCatalogCache(t *testing.T) {
+	t.Parallel()
+
+	srv, requests, notModified := newCatalogServer(t, `"v1"`)
+	dir := t.TempDir()
+	cache := &CatalogCache{Dir: dir, TTL: time.Hour}
+	client := New("en-CA", WithEndpoint(srv.URL), WithCatalogCache(cache))
+
+	ctx := context.Background()
+	if _, err := client.ListModels(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListCategories(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetModelTree(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Fatalf("expected a single catalog download, got %d", got)
+	}
+
+	// a fresh cache on the same directory needs to revalidate once the TTL expired
+	expired := &CatalogCache{Dir: dir, TTL: 0}
+	models, err := New("en-CA", WithEndpoint(srv.URL), WithCatalogCache(expired)).ListModels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Model != "KX040-4" {
+		t.Fatalf("expected cached models after revalidation, got %v", models)
+	}
+	if got := atomic.LoadInt32(notModified); got != 1 {
+		t.Fatalf("expected conditional request to be answered with not modified, got %d", got)
+	}
+}
+
+func TestCatalogCache_Offline(t *testing.T) {
+	t.Parallel()
+
+	srv, requests, _ := newCatalogServer(t, `"v1"`)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	offline := New("en-CA", WithEndpoint(srv.URL), WithCatalogCache(&CatalogCache{Dir: dir, Offline: true}))
+	if _, err := offline.ListModels(ctx); !errors.Is(err, ErrCatalogNotCached) {
+		t.Fatalf("expected missing catalog error, got %v", err)
+	}
+
+	online := New("en-CA", WithEndpoint(srv.URL), WithCatalogCache(&CatalogCache{Dir: dir, TTL: time.Hour}))
+	if _, err := online.ListModels(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	offline = New("en-CA", WithEndpoint(srv.URL), WithCatalogCache(&CatalogCache{Dir: dir, Offline: true}))
+	categories, err := offline.ListCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 1 {
+		t.Fatalf("expected cached categories, got %v", categories)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Fatalf("expected offline clients to never contact the API, got %d requests", got)
+	}
+}
+
+func TestCatalogCache_Endpoints(t *testing.T) {
+	t.Parallel()
+
+	production, _, _ := newCatalogServer(t, `"v1"`)
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"categories":[],"models":[{"model":"L2501"},{"model":"BX2380"}]}`))
+	}))
+	t.Cleanup(fake.Close)
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		// the second round reads the snapshots written to disk by the first one
+		cache := &CatalogCache{Dir: dir, TTL: time.Hour}
+		for endpoint, expected := range map[string]int{production.URL: 1, fake.URL: 2} {
+			models, err := New("en-CA", WithEndpoint(endpoint), WithCatalogCache(cache)).ListModels(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(models) != expected {
+				t.Fatalf("expected %d models from %s, got %v", expected, endpoint, models)
+			}
+		}
+	}
+}
+
+func TestCatalogCache_ConcurrentLocales(t *testing.T) {
+	t.Parallel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Language") == "en-US" {
+			close(started)
+			<-release
+		}
+		w.Write([]byte(`{"categories":[],"models":[{"model":"KX040-4"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	defer close(release)
+
+	ctx := context.Background()
+	cache := &CatalogCache{TTL: time.Hour}
+	slow := make(chan error, 1)
+	go func() {
+		_, err := New("en-US", WithEndpoint(srv.URL), WithCatalogCache(cache)).ListModels(ctx)
+		slow <- err
+	}()
+	<-started
+
+	// a slow download of one locale must not block other locales
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := New("en-CA", WithEndpoint(srv.URL), WithCatalogCache(cache)).ListModels(ctx); err != nil {
+		t.Fatalf("expected en-CA to load while en-US is downloading, got %v", err)
+	}
+	select {
+	case err := <-slow:
+		t.Fatalf("expected en-US to still be downloading, got %v", err)
+	default:
+	}
+}
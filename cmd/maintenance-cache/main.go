@@ -3,55 +3,141 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
-	"sync"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/nicolai86/mykubota"
-	"golang.org/x/sync/semaphore"
+	"github.com/nicolai86/mykubota/output"
+)
+
+var (
+	locales        = flag.String("locale", "en-CA", "comma separated list of locales to snapshot, e.g. en-CA,en-US")
+	outDir         = flag.String("out", ".", "directory snapshots and error reports are written to")
+	fileName       = flag.String("file", "", "snapshot file name. {locale} and {date} are replaced. Defaults to snapshot-{date}.json, or snapshot-{locale}-{date}.json for multiple locales")
+	concurrency    = flag.Int("concurrency", 20, "number of maintenance schedules fetched in parallel")
+	timeout        = flag.Duration("timeout", 30*time.Second, "timeout for a single API request")
+	modelFilter    = flag.String("model", "", "only snapshot models matching this regular expression")
+	maxFailureRate = flag.Float64("max-failure-rate", 0.1, "exit with a non-zero code if more than this fraction of models failed")
 )
 
 func main() {
+	flag.Parse()
+	if *concurrency < 1 {
+		log.Fatalf("concurrency must be at least 1, got %d", *concurrency)
+	}
+	var filter *regexp.Regexp
+	if *modelFilter != "" {
+		re, err := regexp.Compile(*modelFilter)
+		if err != nil {
+			log.Fatalf("invalid model filter: %v", err)
+		}
+		filter = re
+	}
+
+	ls := strings.Split(*locales, ",")
+	name := *fileName
+	if name == "" {
+		name = "snapshot-{date}.json"
+		if len(ls) > 1 {
+			name = "snapshot-{locale}-{date}.json"
+		}
+	}
+
+	// failed locales don't stop the remaining ones, the exit code is decided once all were attempted
+	failed := []string{}
+	for _, locale := range ls {
+		locale = strings.TrimSpace(locale)
+		path := filepath.Join(*outDir, strings.NewReplacer(
+			"{locale}", locale,
+			"{date}", time.Now().Format("20060102"),
+		).Replace(name))
+		ok, err := snapshot(locale, filter, path)
+		if err != nil {
+			log.Printf("unable to snapshot %s: %v\n", locale, err)
+		}
+		if !ok {
+			failed = append(failed, locale)
+		}
+	}
+	if len(failed) > 0 {
+		log.Printf("%d of %d locales failed: %s\n", len(failed), len(ls), strings.Join(failed, ", "))
+		os.Exit(1)
+	}
+}
+
+// snapshot writes the maintenance schedules of all models in the given locale to path,
+// and errors to a separate report next to it. It reports whether the failure rate was acceptable,
+// and returns an error if the locale couldn't be snapshotted at all
+func snapshot(locale string, filter *regexp.Regexp, path string) (bool, error) {
 	ctx := context.Background()
-	c := mykubota.New("en-CA")
+	c := mykubota.New(locale, mykubota.WithHTTPClient(&http.Client{Timeout: *timeout}))
 
 	models, err := c.ListModels(ctx)
 	if err != nil {
-		log.Fatalf("unable to list models: %v", err)
+		return false, fmt.Errorf("unable to list models: %w", err)
 	}
-	log.Printf("fetching maintenance schedule for %d models\n", len(models))
-
-	mu := sync.Mutex{}
-	var maintenanceByModel = map[string][]mykubota.Maintenance{}
-	sem := semaphore.NewWeighted(20)
-
+	names := []string{}
 	for _, model := range models {
-		go func(model mykubota.Model) {
-			sem.Acquire(ctx, 1)
-			defer sem.Release(1)
-			
-			schedule, err := c.MaintenanceSchedule(model.Model)
-			if err != nil {
-				log.Printf("skipping model %q due to error: %v\n", model.Model, err)
+		if filter != nil && !filter.MatchString(model.Model) {
+			continue
+		}
+		names = append(names, model.Model)
+	}
+	log.Printf("fetching maintenance schedule for %d models in %s\n", len(names), locale)
+
+	res, err := c.MaintenanceSchedules(ctx, names, mykubota.MaintenanceSchedulesOptions{
+		Concurrency: *concurrency,
+		Progress: func(p mykubota.MaintenanceSchedulesProgress) {
+			if p.Err != nil {
+				log.Printf("[%d/%d] skipping model %q due to error: %v\n", p.Done, p.Total, p.Model, p.Err)
 			}
-			
-			mu.Lock()
-			maintenanceByModel[model.Model] = schedule
-			mu.Unlock()
-		}(model)
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("unable to fetch maintenance schedules: %w", err)
+	}
+	errorsByModel := map[string]string{}
+	for model, err := range res.Errors {
+		errorsByModel[model] = err.Error()
 	}
-	sem.Acquire(ctx, 20)
 
-	year, month, day := time.Now().Date()
-	payload := bytes.Buffer{}
-	if err := json.NewEncoder(&payload).Encode(maintenanceByModel); err != nil {
-		log.Fatalf("unable to encode maintenance snapshot: %v", err)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("unable to create output directory: %w", err)
+	}
+	// the error report is written even if the snapshot can't be, so failures are never lost
+	var reportErr error
+	if len(errorsByModel) > 0 {
+		errorsPath := strings.TrimSuffix(path, ".json") + ".errors.json"
+		reportErr = writeJSON(errorsPath, errorsByModel)
+		log.Printf("%d of %d models failed in %s, see %s\n", len(errorsByModel), len(names), locale, errorsPath)
+	}
+	if err := writeJSON(path, res.Schedules); err != nil {
+		return false, err
+	}
+	if reportErr != nil {
+		return false, reportErr
+	}
+
+	if len(names) == 0 {
+		return true, nil
+	}
+	return float64(len(errorsByModel))/float64(len(names)) <= *maxFailureRate, nil
+}
 
+func writeJSON(path string, v any) error {
+	payload := bytes.Buffer{}
+	if err := output.Write(&payload, v, output.Options{Format: output.JSON}); err != nil {
+		return fmt.Errorf("unable to encode %s: %w", path, err)
 	}
-	if err := os.WriteFile(fmt.Sprintf("snapshot-%d%d%d.json", year, month, day), payload.Bytes(), 0644); err != nil {
-		log.Fatalf("unable to write snapshot file; %v", err)
+	if err := os.WriteFile(path, payload.Bytes(), 0644); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
 	}
+	return nil
 }
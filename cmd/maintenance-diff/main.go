@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/output"
+)
+
+var (
+	format   = flag.String("format", "text", "output format: text, markdown, json or yaml")
+	exitCode = flag.Bool("exit-code", false, "exit with code 1 if the snapshots differ")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <old snapshot> <new snapshot>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	old := readSnapshot(flag.Arg(0))
+	new := readSnapshot(flag.Arg(1))
+	diff := mykubota.DiffMaintenanceSnapshots(old, new)
+
+	var err error
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, diff)
+	case "markdown":
+		err = writeMarkdown(os.Stdout, diff, flag.Arg(0), flag.Arg(1))
+	case output.JSON, output.YAML:
+		err = output.Write(os.Stdout, diff, output.Options{Format: *format})
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatalf("unable to write diff: %v", err)
+	}
+
+	if *exitCode && !diff.Empty() {
+		os.Exit(1)
+	}
+}
+
+func readSnapshot(path string) mykubota.MaintenanceSnapshot {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("unable to open snapshot: %v", err)
+	}
+	defer f.Close()
+	snapshot := mykubota.MaintenanceSnapshot{}
+	if err := json.NewDecoder(f).Decode(&snapshot); err != nil {
+		log.Fatalf("unable to decode snapshot %s: %v", path, err)
+	}
+	return snapshot
+}
+
+var kindSymbols = map[string]string{
+	mykubota.MaintenanceAdded:   "+",
+	mykubota.MaintenanceRemoved: "-",
+	mykubota.MaintenanceChanged: "~",
+}
+
+func writeText(w io.Writer, diff mykubota.MaintenanceDiff) error {
+	b := strings.Builder{}
+	if diff.Empty() {
+		b.WriteString("no changes\n")
+	}
+	for _, model := range diff.AddedModels {
+		fmt.Fprintf(&b, "+ %s\n", model)
+	}
+	for _, model := range diff.RemovedModels {
+		fmt.Fprintf(&b, "- %s\n", model)
+	}
+	for _, model := range diff.ChangedModels {
+		fmt.Fprintf(&b, "~ %s\n", model.Model)
+		for _, item := range model.Items {
+			fmt.Fprintf(&b, "    %s %s (%s)\n", kindSymbols[item.Kind], item.CheckPoint, item.ID)
+			for _, change := range item.Changes {
+				fmt.Fprintf(&b, "        %s: %v -> %v\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdown(w io.Writer, diff mykubota.MaintenanceDiff, oldPath, newPath string) error {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "# Maintenance schedule changes\n\nComparing `%s` to `%s`.\n\n", oldPath, newPath)
+	if diff.Empty() {
+		b.WriteString("No changes.\n")
+	}
+	if len(diff.AddedModels) > 0 {
+		b.WriteString("## Added models\n\n")
+		for _, model := range diff.AddedModels {
+			fmt.Fprintf(&b, "- %s\n", model)
+		}
+		b.WriteString("\n")
+	}
+	if len(diff.RemovedModels) > 0 {
+		b.WriteString("## Removed models\n\n")
+		for _, model := range diff.RemovedModels {
+			fmt.Fprintf(&b, "- %s\n", model)
+		}
+		b.WriteString("\n")
+	}
+	if len(diff.ChangedModels) > 0 {
+		b.WriteString("## Changed models\n\n")
+		for _, model := range diff.ChangedModels {
+			fmt.Fprintf(&b, "### %s\n\n| Check point | ID | Change | Field | Old | New |\n|---|---|---|---|---|---|\n", model.Model)
+			for _, item := range model.Items {
+				if len(item.Changes) == 0 {
+					fmt.Fprintf(&b, "| %s | %s | %s | | | |\n", markdownEscape(item.CheckPoint), item.ID, item.Kind)
+					continue
+				}
+				for _, change := range item.Changes {
+					fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+						markdownEscape(item.CheckPoint), item.ID, item.Kind, change.Field,
+						markdownEscape(fmt.Sprint(change.Old)), markdownEscape(fmt.Sprint(change.New)))
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,103 @@
+// Command mykubota-fake serves a fake MyKubota API with simulated telematics for local development
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nicolai86/mykubota/mykubotatest"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	addr         = flag.String("addr", "localhost:8080", "address to listen on")
+	fixturesPath = flag.String("fixtures", "", "JSON or YAML fixture file. Defaults to built-in fixtures")
+	dump         = flag.Bool("dump", false, "print the built-in fixtures as JSON and exit, as a starting point for a fixture file")
+	tick         = flag.Duration("tick", 5*time.Second, "interval between telematics updates, 0 disables the simulation")
+	speed        = flag.Float64("speed", 60, "simulated time per real time, e.g. 60 lets a machine run an hour per minute")
+	seed         = flag.Int64("seed", 0, "random seed of the simulation. Defaults to the current time")
+)
+
+func main() {
+	flag.Parse()
+	if *dump {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(mykubotatest.DefaultFixtures()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fixtures := mykubotatest.DefaultFixtures()
+	if *fixturesPath != "" {
+		f, err := loadFixtures(*fixturesPath)
+		if err != nil {
+			log.Fatalf("unable to load fixtures: %v", err)
+		}
+		fixtures = f
+	}
+	h := mykubotatest.NewHandler(fixtures)
+
+	if *tick > 0 {
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		sim := &simulator{rand: rand.New(rand.NewSource(*seed)), speed: *speed}
+		go func() {
+			for now := range time.Tick(*tick) {
+				h.Seed(func(f *mykubotatest.Fixtures) {
+					sim.step(f, now, *tick)
+				})
+			}
+		}()
+	}
+
+	log.Printf("serving fake MyKubota API on http://%s", *addr)
+	for _, a := range fixtures.Accounts {
+		log.Printf("account %s, password %s", a.Username, a.Password)
+	}
+	log.Fatal(http.ListenAndServe(*addr, logRequests(h)))
+}
+
+// loadFixtures reads fixtures from a JSON or YAML file, depending on its extension.
+// YAML uses the same field names as JSON
+func loadFixtures(path string) (mykubotatest.Fixtures, error) {
+	fixtures := mykubotatest.Fixtures{}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return fixtures, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		var v any
+		if err := yaml.Unmarshal(bs, &v); err != nil {
+			return fixtures, err
+		}
+		if bs, err = json.Marshal(v); err != nil {
+			return fixtures, err
+		}
+	default:
+		return fixtures, fmt.Errorf("unsupported fixture file %s, expected .json, .yaml or .yml", path)
+	}
+	if err := json.Unmarshal(bs, &fixtures); err != nil {
+		return fixtures, err
+	}
+	return fixtures, nil
+}
+
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s %s", r.Method, r.URL)
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/mykubotatest"
+)
+
+// faultCodes are picked at random when a running machine develops a fault
+var faultCodes = []map[string]any{
+	{"code": "P0217", "description": "Engine coolant over temperature"},
+	{"code": "P0087", "description": "Fuel rail pressure too low"},
+	{"code": "P20EE", "description": "SCR NOx catalyst efficiency below threshold"},
+	{"code": "U0100", "description": "Lost communication with engine control module"},
+}
+
+// simulator advances the telematics of all telematics enabled equipment
+type simulator struct {
+	rand  *rand.Rand
+	speed float64
+}
+
+func (s *simulator) step(f *mykubotatest.Fixtures, now time.Time, elapsed time.Duration) {
+	hours := elapsed.Hours() * s.speed
+	for _, a := range f.Accounts {
+		for i := range a.Equipment {
+			eq := &a.Equipment[i]
+			if !eq.HasTelematics {
+				continue
+			}
+			s.advance(&eq.Telematics, now, hours)
+			eq.HasFaultCodes = len(eq.Telematics.FaultCodes) > 0
+		}
+	}
+}
+
+// advance simulates hours of operation: machines start and stop at random, burn fuel
+// and DEF while running and move around their current location
+func (s *simulator) advance(t *mykubota.EquipmentTelematics, now time.Time, hours float64) {
+	if s.rand.Float64() < 0.2 {
+		t.EngineRunning = !t.EngineRunning
+		if t.EngineRunning {
+			t.RunNumber++
+		}
+	}
+	t.LocationTime = now.UTC()
+	if t.AmbientAirTempCelsius == 0 {
+		t.AmbientAirTempCelsius = 18
+	}
+
+	if !t.EngineRunning {
+		t.EngineRPM = 0
+		t.MotionState = "parked"
+		t.CoolantTempCelsius = approach(t.CoolantTempCelsius, int(t.AmbientAirTempCelsius), 10)
+		t.HydraulicTempCelsius = approach(t.HydraulicTempCelsius, int(t.AmbientAirTempCelsius), 8)
+		// parked machines get refueled eventually
+		if t.FuelRemainingPercent < 15 && s.rand.Float64() < 0.5 {
+			t.FuelRemainingPercent = 100
+			t.DEFRemainingPercent = 100
+		}
+		return
+	}
+
+	t.CumulativeOperatingHours += hours
+	t.EngineRPM = 1800 + s.rand.Intn(600)
+	t.CoolantTempCelsius = approach(t.CoolantTempCelsius, 88, 15)
+	t.HydraulicTempCelsius = approach(t.HydraulicTempCelsius, 60, 10)
+	t.FuelTempCelsius = approach(t.FuelTempCelsius, 40, 5)
+	t.ExtPowerVolts = 13.8 + s.rand.Float64()*0.4
+	t.FuelRemainingPercent = approach(t.FuelRemainingPercent, 0, s.roundRandomly(hours*8*s.rand.Float64()))
+	t.DEFRemainingPercent = math.Max(0, t.DEFRemainingPercent-hours*0.8*s.rand.Float64())
+
+	t.MotionState = "stationary"
+	if s.rand.Float64() < 0.6 {
+		t.MotionState = "moving"
+		t.Location.Latitude += (s.rand.Float64() - 0.5) * 0.001
+		t.Location.Longitude += (s.rand.Float64() - 0.5) * 0.001
+		t.Location.PositionHeadingAngle = float64(s.rand.Intn(360))
+	}
+
+	switch r := s.rand.Float64(); {
+	case r < 0.03:
+		fault := map[string]any{"occurredAt": now.UTC()}
+		for k, v := range faultCodes[s.rand.Intn(len(faultCodes))] {
+			fault[k] = v
+		}
+		t.FaultCodes = append(t.FaultCodes, fault)
+	case r < 0.08 && len(t.FaultCodes) > 0:
+		t.FaultCodes = t.FaultCodes[1:]
+	}
+}
+
+// roundRandomly rounds v up with a probability matching its fraction, so small
+// changes accumulate over many steps
+func (s *simulator) roundRandomly(v float64) int {
+	i, frac := math.Modf(v)
+	if s.rand.Float64() < frac {
+		i++
+	}
+	return int(i)
+}
+
+// approach moves current towards target by at most step
+func approach(current, target, step int) int {
+	if current < target {
+		return int(math.Min(float64(current+step), float64(target)))
+	}
+	return int(math.Max(float64(current-step), float64(target)))
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/posener/complete"
+)
+
+// CatalogModelsCommand lists all models offered by Kubota
+type CatalogModelsCommand struct {
+	Meta
+}
+
+func (c *CatalogModelsCommand) Synopsis() string {
+	return "List all models"
+}
+
+func (c *CatalogModelsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CatalogModelsCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *CatalogModelsCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota catalog models [options]
+
+  Lists all machines and attachments offered by Kubota in your locale.
+` + generalOptions + outputOptions)
+}
+
+func (c *CatalogModelsCommand) Run(args []string) int {
+	fs := c.flagSet("catalog models")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	models, err := c.client().ListModels(context.Background())
+	if err != nil {
+		return c.errorf("unable to list models: %v", err)
+	}
+	return c.print(models, "model", "type", "categoryId", "hasMaintenanceSchedules")
+}
+
+// CatalogCategoriesCommand lists all product categories
+type CatalogCategoriesCommand struct {
+	Meta
+}
+
+func (c *CatalogCategoriesCommand) Synopsis() string {
+	return "List all categories"
+}
+
+func (c *CatalogCategoriesCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CatalogCategoriesCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *CatalogCategoriesCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota catalog categories [options]
+
+  Lists all product categories offered by Kubota in your locale.
+` + generalOptions + outputOptions)
+}
+
+func (c *CatalogCategoriesCommand) Run(args []string) int {
+	fs := c.flagSet("catalog categories")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	categories, err := c.client().ListCategories(context.Background())
+	if err != nil {
+		return c.errorf("unable to list categories: %v", err)
+	}
+	return c.print(categories, "id", "name", "parentId")
+}
+
+// CatalogTreeCommand prints the category tree including models
+type CatalogTreeCommand struct {
+	Meta
+}
+
+func (c *CatalogTreeCommand) Synopsis() string {
+	return "Show the category tree"
+}
+
+func (c *CatalogTreeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CatalogTreeCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-models": complete.PredictNothing,
+	})
+}
+
+func (c *CatalogTreeCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota catalog tree [options]
+
+  Shows all categories as a tree, including the number of models per category.
+
+Options:
+
+  -models  Also list the models of every category.
+` + generalOptions)
+}
+
+func (c *CatalogTreeCommand) Run(args []string) int {
+	var withModels bool
+	fs := c.flagSet("catalog tree")
+	fs.BoolVar(&withModels, "models", false, "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	roots, err := c.client().GetModelTree(context.Background())
+	if err != nil {
+		return c.errorf("unable to load model tree: %v", err)
+	}
+	for _, root := range roots {
+		if root.Orphaned() {
+			c.Ui.Warn(fmt.Sprintf("category %s (%d) references parent %d, which is missing or forms a cycle; showing it as root", root.Name, root.ID, *root.ParentID))
+		}
+	}
+
+	b := strings.Builder{}
+	for _, root := range roots {
+		root.Walk(func(node *mykubota.CategoryNode) error {
+			indent := strings.Repeat("  ", len(node.Path())-1)
+			fmt.Fprintf(&b, "%s%s (%d)\n", indent, node.Name, node.ModelCount())
+			if withModels {
+				for _, m := range node.Models {
+					fmt.Fprintf(&b, "%s  - %s\n", indent, m.Model)
+				}
+			}
+			return nil
+		})
+	}
+	c.Ui.Output(strings.TrimSuffix(b.String(), "\n"))
+	return 0
+}
+
+// CatalogSearchCommand searches models by partial model name, PIN and serial
+type CatalogSearchCommand struct {
+	Meta
+}
+
+func (c *CatalogSearchCommand) Synopsis() string {
+	return "Search models by model name, PIN or serial"
+}
+
+func (c *CatalogSearchCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CatalogSearchCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-model":  c.predictModels(),
+		"-serial": complete.PredictAnything,
+	}, outputCompleteFlags)
+}
+
+func (c *CatalogSearchCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota catalog search [options]
+
+  Searches Kubotas registry and the catalog for models matching a partial
+  model name, a PIN or a serial number. All candidates are listed, best
+  match first, with the reasons why they matched.
+
+Options:
+
+  -model=<model>    Partial model name, e.g. kx0.
+  -serial=<serial>  PIN or serial number.
+` + generalOptions + outputOptions)
+}
+
+func (c *CatalogSearchCommand) Run(args []string) int {
+	var model, serial string
+	fs := c.flagSet("catalog search")
+	c.outputFlags(fs, "table")
+	fs.StringVar(&model, "model", "", "")
+	fs.StringVar(&serial, "serial", "", "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if model == "" && serial == "" {
+		return c.errorf("-model or -serial is required\n\n%s", c.Help())
+	}
+
+	candidates, err := c.client().SearchMachines(context.Background(), mykubota.SearchMachineRequest{
+		PartialModel: model,
+		Serial:       serial,
+	})
+	if err != nil {
+		return c.errorf("unable to search model: %v", err)
+	}
+
+	type candidateRow struct {
+		Model      string `json:"model"`
+		Type       string `json:"type"`
+		CategoryID int    `json:"categoryId"`
+		Score      int    `json:"score"`
+		Reasons    string `json:"reasons"`
+	}
+	rows := make([]candidateRow, 0, len(candidates))
+	for _, candidate := range candidates {
+		reasons := make([]string, 0, len(candidate.Matches))
+		for _, match := range candidate.Matches {
+			reasons = append(reasons, match.Detail)
+		}
+		rows = append(rows, candidateRow{
+			Model:      candidate.Model.Model,
+			Type:       candidate.Model.Type,
+			CategoryID: candidate.Model.CategoryID,
+			Score:      candidate.Score,
+			Reasons:    strings.Join(reasons, "; "),
+		})
+	}
+	return c.print(rows, "model", "score", "reasons")
+}
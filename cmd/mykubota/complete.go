@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/output"
+	"github.com/posener/complete"
+)
+
+const (
+	// completionTimeout bounds API calls made while completing, so the shell never hangs
+	completionTimeout = 3 * time.Second
+	equipmentCacheTTL = 10 * time.Minute
+	scheduleCacheTTL  = 24 * time.Hour
+)
+
+// generalFlags are accepted by every command
+var generalFlags = complete.Flags{
+	"-locale":  complete.PredictSet("en-US", "en-CA"),
+	"-profile": (&Meta{}).predictProfiles(),
+}
+
+// outputCompleteFlags are accepted by every command rendering results
+var outputCompleteFlags = complete.Flags{
+	"-output":  complete.PredictSet(output.Table, output.JSON, output.NDJSON, output.CSV, output.YAML),
+	"-columns": complete.PredictAnything,
+	"-format":  complete.PredictAnything,
+}
+
+func mergeFlags(flags ...complete.Flags) complete.Flags {
+	merged := complete.Flags{}
+	for _, fs := range flags {
+		for name, p := range fs {
+			merged[name] = p
+		}
+	}
+	return merged
+}
+
+type equipmentRef struct {
+	ID       string `json:"id"`
+	Nickname string `json:"nickname"`
+	Model    string `json:"model"`
+}
+
+type cachedEquipment struct {
+	FetchedAt time.Time      `json:"fetchedAt"`
+	Items     []equipmentRef `json:"items"`
+}
+
+type cachedSchedule struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	IDs       []string  `json:"ids"`
+}
+
+// completionCache keeps values needed for dynamic completions on disk
+type completionCache struct {
+	Equipment map[string]cachedEquipment `json:"equipment,omitempty"`
+	Schedules map[string]cachedSchedule  `json:"schedules,omitempty"`
+}
+
+func (m *Meta) completionCachePath() (string, error) {
+	dir, err := m.cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "completion.json"), nil
+}
+
+func (m *Meta) loadCompletionCache() *completionCache {
+	cache := &completionCache{}
+	path, err := m.completionCachePath()
+	if err != nil {
+		return cache
+	}
+	if bs, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(bs, cache)
+	}
+	if cache.Equipment == nil {
+		cache.Equipment = map[string]cachedEquipment{}
+	}
+	if cache.Schedules == nil {
+		cache.Schedules = map[string]cachedSchedule{}
+	}
+	return cache
+}
+
+func (m *Meta) storeCompletionCache(cache *completionCache) {
+	path, err := m.completionCachePath()
+	if err != nil {
+		return
+	}
+	bs, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	os.WriteFile(path, bs, 0600)
+}
+
+// parseCompletionFlags applies -profile and -locale flags of a partial command line
+func (m *Meta) parseCompletionFlags(args complete.Args) {
+	targets := map[string]*string{
+		"locale":  &m.locale,
+		"profile": &m.profile,
+	}
+	for i, arg := range args.Completed {
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if idx := strings.Index(name, "="); idx >= 0 {
+			if target, ok := targets[name[:idx]]; ok {
+				*target = name[idx+1:]
+			}
+		} else if target, ok := targets[name]; ok && i+1 < len(args.Completed) {
+			*target = args.Completed[i+1]
+		}
+	}
+}
+
+// cachedEquipmentRefs returns the equipment of the logged in user, refreshing the cache if needed
+func (m *Meta) cachedEquipmentRefs(args complete.Args) []equipmentRef {
+	m.parseCompletionFlags(args)
+	profile := m.currentProfile()
+	cache := m.loadCompletionCache()
+	cached, ok := cache.Equipment[profile]
+	if ok && time.Since(cached.FetchedAt) < equipmentCacheTTL {
+		return cached.Items
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	session, err := m.session(ctx)
+	if err != nil {
+		return cached.Items
+	}
+	eqs, err := session.ListEquipment(ctx)
+	if err != nil {
+		return cached.Items
+	}
+	refs := []equipmentRef{}
+	for _, eq := range eqs {
+		refs = append(refs, equipmentRef{ID: eq.ID, Nickname: eq.Nickname, Model: eq.Model})
+	}
+	cache.Equipment[profile] = cachedEquipment{FetchedAt: time.Now(), Items: refs}
+	m.storeCompletionCache(cache)
+	return refs
+}
+
+// predictEquipment completes equipment IDs and nicknames
+func (m *Meta) predictEquipment() complete.Predictor {
+	return complete.PredictFunc(func(args complete.Args) []string {
+		predictions := []string{}
+		for _, ref := range m.cachedEquipmentRefs(args) {
+			predictions = append(predictions, ref.ID)
+			if ref.Nickname != "" {
+				predictions = append(predictions, ref.Nickname)
+			}
+		}
+		return predictions
+	})
+}
+
+// predictModels completes model names from the catalog cache
+func (m *Meta) predictModels() complete.Predictor {
+	return complete.PredictFunc(func(args complete.Args) []string {
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		defer cancel()
+
+		dir, err := m.cacheDir()
+		if err != nil {
+			return nil
+		}
+		m.parseCompletionFlags(args)
+		locale := m.currentLocale()
+		models, err := mykubota.New(locale, mykubota.WithCatalogCache(&mykubota.CatalogCache{Dir: dir, Offline: true})).ListModels(ctx)
+		if errors.Is(err, mykubota.ErrCatalogNotCached) {
+			models, err = mykubota.New(locale,
+				mykubota.WithHTTPClient(&http.Client{Timeout: completionTimeout}),
+				mykubota.WithCatalogCache(&mykubota.CatalogCache{Dir: dir, TTL: 24 * time.Hour}),
+			).ListModels(ctx)
+		}
+		if err != nil {
+			return nil
+		}
+		predictions := []string{}
+		for _, model := range models {
+			predictions = append(predictions, model.Model)
+		}
+		sort.Strings(predictions)
+		return predictions
+	})
+}
+
+// predictCheckPoints completes comma separated maintenance schedule IDs for the equipment
+// given as positional argument
+func (m *Meta) predictCheckPoints() complete.Predictor {
+	return complete.PredictFunc(func(args complete.Args) []string {
+		equipmentID := ""
+		for i, arg := range args.Completed {
+			if strings.HasPrefix(arg, "-") || (i > 0 && strings.HasPrefix(args.Completed[i-1], "-") && !strings.Contains(args.Completed[i-1], "=")) {
+				continue
+			}
+			equipmentID = arg
+		}
+		model := ""
+		for _, ref := range m.cachedEquipmentRefs(args) {
+			if ref.ID == equipmentID || ref.Nickname == equipmentID {
+				model = ref.Model
+			}
+		}
+		if model == "" {
+			return nil
+		}
+
+		ids := m.cachedCheckPoints(m.currentLocale(), model)
+		prefix := ""
+		if idx := strings.LastIndex(args.Last, ","); idx >= 0 {
+			prefix = args.Last[:idx+1]
+		}
+		predictions := []string{}
+		for _, id := range ids {
+			predictions = append(predictions, prefix+id)
+		}
+		return predictions
+	})
+}
+
+func (m *Meta) cachedCheckPoints(locale, model string) []string {
+	cache := m.loadCompletionCache()
+	key := locale + "/" + model
+	if cached, ok := cache.Schedules[key]; ok && time.Since(cached.FetchedAt) < scheduleCacheTTL {
+		return cached.IDs
+	}
+
+	schedule, err := mykubota.New(locale, mykubota.WithHTTPClient(&http.Client{Timeout: completionTimeout})).MaintenanceSchedule(model)
+	if err != nil {
+		return cache.Schedules[key].IDs
+	}
+	ids := []string{}
+	for _, m := range schedule {
+		ids = append(ids, m.ID)
+	}
+	cache.Schedules[key] = cachedSchedule{FetchedAt: time.Now(), IDs: ids}
+	m.storeCompletionCache(cache)
+	return ids
+}
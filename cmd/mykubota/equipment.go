@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/filter"
+	"github.com/nicolai86/mykubota/pin"
+	"github.com/posener/complete"
+)
+
+// EquipmentListCommand lists all equipment of the account
+type EquipmentListCommand struct {
+	Meta
+}
+
+func (c *EquipmentListCommand) Synopsis() string {
+	return "List your equipment"
+}
+
+func (c *EquipmentListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *EquipmentListCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-filter": complete.PredictAnything,
+	}, outputCompleteFlags)
+}
+
+func (c *EquipmentListCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota equipment list [options]
+
+  Lists all equipment registered with your MyKubota account.
+
+Options:
+
+  -filter=<expr>  Only list equipment matching the expression, e.g.
+                  'category == "Tractors" && telematics.fuelRemainingPercent < 20'
+                  or 'hasTelematics && telematics.locationTime older 2h'.
+` + generalOptions + outputOptions)
+}
+
+func (c *EquipmentListCommand) Run(args []string) int {
+	fs := c.flagSet("equipment list")
+	expr := fs.String("filter", "", "")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	selector, err := filter.Compile[mykubota.Equipment](*expr)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	eqs, err := session.ListEquipment(ctx)
+	if err != nil {
+		return c.errorf("unable to list equipment: %v", err)
+	}
+	return c.print(selector.Select(eqs), "id", "nickName", "model", "category", "pinOrSerial", "hasTelematics")
+}
+
+// EquipmentGetCommand shows details of a single equipment
+type EquipmentGetCommand struct {
+	Meta
+}
+
+func (c *EquipmentGetCommand) Synopsis() string {
+	return "Show equipment details"
+}
+
+func (c *EquipmentGetCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictEquipment()
+}
+
+func (c *EquipmentGetCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *EquipmentGetCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota equipment get [options] <equipment>
+
+  Shows all details of the equipment, including telematics. Equipment is
+  identified by its ID, nickname, PIN, serial number or model.
+` + generalOptions + outputOptions)
+}
+
+func (c *EquipmentGetCommand) Run(args []string) int {
+	fs := c.flagSet("equipment get")
+	c.outputFlags(fs, "json")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one equipment\n\n%s", c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	found, err := session.FindEquipment(ctx, fs.Arg(0))
+	if err != nil {
+		return c.errorf("unable to find equipment: %v", err)
+	}
+	eq, err := session.GetEquipment(ctx, found.ID)
+	if err != nil {
+		return c.errorf("unable to get equipment: %v", err)
+	}
+	return c.print(eq)
+}
+
+// EquipmentAddCommand registers new equipment with the account
+type EquipmentAddCommand struct {
+	Meta
+}
+
+func (c *EquipmentAddCommand) Synopsis() string {
+	return "Add equipment to your account"
+}
+
+func (c *EquipmentAddCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *EquipmentAddCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-model":  c.predictModels(),
+		"-serial": complete.PredictAnything,
+	})
+}
+
+func (c *EquipmentAddCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota equipment add [options]
+
+  Registers a machine with your MyKubota account.
+
+Options:
+
+  -model=<model>    Model name, e.g. KX040-4. Required.
+  -serial=<serial>  PIN or serial number of the machine. Required.
+                    17 character PINs are normalized and sent as PIN.
+                    Identifiers which look like neither a PIN nor a
+                    serial number are sent after a warning.
+` + generalOptions)
+}
+
+func (c *EquipmentAddCommand) Run(args []string) int {
+	var modelName, serial string
+	fs := c.flagSet("equipment add")
+	fs.StringVar(&modelName, "model", "", "")
+	fs.StringVar(&serial, "serial", "", "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if modelName == "" || serial == "" {
+		return c.errorf("-model and -serial are required\n\n%s", c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	model, err := c.findModel(ctx, modelName, serial)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	if pin.Identify(serial) == pin.KindInvalid {
+		c.Ui.Warn(fmt.Sprintf("%q doesn't look like a PIN or serial number, sending it anyway", serial))
+	}
+	if err := session.AddEquipment(ctx, mykubota.AddEquipmentRequest{
+		Model:       model,
+		PinOrSerial: serial,
+	}); err != nil {
+		return c.errorf("unable to add equipment: %v", err)
+	}
+	c.Ui.Info(fmt.Sprintf("Added %s %s", model.Model, serial))
+	return 0
+}
+
+func (c *EquipmentAddCommand) findModel(ctx context.Context, name, serial string) (*mykubota.Model, error) {
+	client := c.client()
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list models: %v", err)
+	}
+	for _, model := range models {
+		if strings.EqualFold(model.Model, name) {
+			return &model, nil
+		}
+	}
+	model, err := client.SearchMachine(ctx, mykubota.SearchMachineRequest{
+		PartialModel: name,
+		Serial:       serial,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find model %q: %v", name, err)
+	}
+	return model, nil
+}
+
+// EquipmentUpdateCommand changes nickname or engine hours of equipment
+type EquipmentUpdateCommand struct {
+	Meta
+}
+
+func (c *EquipmentUpdateCommand) Synopsis() string {
+	return "Change equipment nickname or engine hours"
+}
+
+func (c *EquipmentUpdateCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictEquipment()
+}
+
+func (c *EquipmentUpdateCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-nickname":     complete.PredictAnything,
+		"-engine-hours": complete.PredictAnything,
+	}, outputCompleteFlags)
+}
+
+func (c *EquipmentUpdateCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota equipment update [options] <equipment>
+
+  Updates the nickname or engine hours of the equipment. Equipment is
+  identified by its ID, nickname, PIN, serial number or model.
+  Attributes which are not specified are left unchanged.
+
+Options:
+
+  -nickname=<name>      New nickname.
+  -engine-hours=<hours> New engine hours.
+` + generalOptions + outputOptions)
+}
+
+func (c *EquipmentUpdateCommand) Run(args []string) int {
+	var nickname string
+	var engineHours float64
+	fs := c.flagSet("equipment update")
+	c.outputFlags(fs, "json")
+	fs.StringVar(&nickname, "nickname", "", "")
+	fs.Float64Var(&engineHours, "engine-hours", -1, "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one equipment\n\n%s", c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	found, err := session.FindEquipment(ctx, fs.Arg(0))
+	if err != nil {
+		return c.errorf("unable to find equipment: %v", err)
+	}
+	// equipment lists may be incomplete, so unchanged attributes are taken from the details
+	eq, err := session.GetEquipment(ctx, found.ID)
+	if err != nil {
+		return c.errorf("unable to get equipment: %v", err)
+	}
+	req := mykubota.UpdateEquipmentRequest{
+		EquipmentID: eq.ID,
+		EngineHours: eq.UserEnteredEngineHours,
+		NickName:    eq.Nickname,
+	}
+	if nickname != "" {
+		req.NickName = nickname
+	}
+	if engineHours >= 0 {
+		req.EngineHours = engineHours
+	}
+	updated, err := session.UpdateEquipment(ctx, req)
+	if err != nil {
+		return c.errorf("unable to update equipment: %v", err)
+	}
+	return c.print(updated)
+}
+
+// EquipmentDeleteCommand removes equipment from the account
+type EquipmentDeleteCommand struct {
+	Meta
+}
+
+func (c *EquipmentDeleteCommand) Synopsis() string {
+	return "Remove equipment from your account"
+}
+
+func (c *EquipmentDeleteCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictEquipment()
+}
+
+func (c *EquipmentDeleteCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-force": complete.PredictNothing,
+	})
+}
+
+func (c *EquipmentDeleteCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota equipment delete [options] <equipment>
+
+  Removes the equipment from your MyKubota account. Equipment is identified
+  by its ID, or its whole nickname, PIN or serial number. Partial matches are
+  not accepted. Unless the ID is given, the machine is shown and has to be
+  confirmed.
+
+Options:
+
+  -force  Delete without confirmation.
+` + generalOptions)
+}
+
+func (c *EquipmentDeleteCommand) Run(args []string) int {
+	fs := c.flagSet("equipment delete")
+	force := fs.Bool("force", false, "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one equipment\n\n%s", c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	eq, err := session.FindEquipmentExact(ctx, fs.Arg(0))
+	if err != nil {
+		return c.errorf("unable to find equipment: %v", err)
+	}
+	if !*force && eq.ID != strings.TrimSpace(fs.Arg(0)) {
+		machine := fmt.Sprintf("id %s, %s, %s", eq.ID, eq.Model, eq.PinOrSerial)
+		if eq.Nickname != "" {
+			machine = fmt.Sprintf("%s (%s)", eq.Nickname, machine)
+		}
+		answer, err := c.Ui.Ask(fmt.Sprintf("Delete %s? Type yes to confirm:", machine))
+		if err != nil {
+			return c.errorf("%v", err)
+		}
+		if strings.TrimSpace(strings.ToLower(answer)) != "yes" {
+			c.Ui.Warn("Aborted")
+			return 1
+		}
+	}
+	if err := session.DeleteEquipment(ctx, eq.ID); err != nil {
+		return c.errorf("unable to delete equipment: %v", err)
+	}
+	c.Ui.Info("Deleted " + eq.ID)
+	return 0
+}
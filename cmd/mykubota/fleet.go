@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/filter"
+	"github.com/posener/complete"
+)
+
+// FleetSummaryCommand shows aggregated status of all equipment
+type FleetSummaryCommand struct {
+	Meta
+}
+
+func (c *FleetSummaryCommand) Synopsis() string {
+	return "Show aggregated status of your equipment"
+}
+
+func (c *FleetSummaryCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *FleetSummaryCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-low-fuel":    complete.PredictAnything,
+		"-low-def":     complete.PredictAnything,
+		"-stale-after": complete.PredictAnything,
+		"-filter":      complete.PredictAnything,
+	}, outputCompleteFlags)
+}
+
+func (c *FleetSummaryCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota fleet summary [options]
+
+  Summarizes all equipment registered with your MyKubota account: counts by
+  category and model, total operating hours, machines with active faults,
+  low fuel or DEF, running engines and the age of telematics locations.
+
+Options:
+
+  -low-fuel=<percent>      Fuel level below which machines are listed.
+                           Defaults to 20.
+  -low-def=<percent>       DEF level below which machines are listed.
+                           Defaults to 15.
+  -stale-after=<duration>  Age after which telematics locations are stale,
+                           e.g. 12h. Defaults to 24h.
+  -filter=<expr>           Only summarize equipment matching the expression,
+                           e.g. 'category == "Tractors"'.
+` + generalOptions + outputOptions)
+}
+
+func (c *FleetSummaryCommand) Run(args []string) int {
+	ctx := context.Background()
+	fleet := &mykubota.Fleet{}
+	fs := c.flagSet("fleet summary")
+	fs.IntVar(&fleet.LowFuelPercent, "low-fuel", mykubota.DefaultLowFuelPercent, "")
+	fs.Float64Var(&fleet.LowDEFPercent, "low-def", mykubota.DefaultLowDEFPercent, "")
+	fs.DurationVar(&fleet.StaleAfter, "stale-after", mykubota.DefaultStaleAfter, "")
+	expr := fs.String("filter", "", "")
+	c.outputFlags(fs, "json")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	selector, err := filter.Compile[mykubota.Equipment](*expr)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	loaded, err := session.Fleet(ctx)
+	if err != nil {
+		return c.errorf("unable to load fleet: %v", err)
+	}
+	fleet.Equipment, fleet.FetchedAt = selector.Select(loaded.Equipment), loaded.FetchedAt
+	return c.print(fleet.Summary())
+}
+
+// FleetChangesCommand reports what changed since the last run
+type FleetChangesCommand struct {
+	Meta
+}
+
+func (c *FleetChangesCommand) Synopsis() string {
+	return "Show what changed since the last run"
+}
+
+func (c *FleetChangesCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.json")
+}
+
+func (c *FleetChangesCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-engine-hours-jump": complete.PredictAnything,
+		"-dry-run":           complete.PredictNothing,
+	}, outputCompleteFlags)
+}
+
+func (c *FleetChangesCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota fleet changes [options] <snapshot>
+
+  Compares all equipment registered with your MyKubota account to the
+  snapshot file and lists the changes: equipment added or removed, nickname
+  changes, engine hours jumps, new and cleared fault codes, geofences
+  entered or left and restart inhibit changes. Afterwards the snapshot is
+  replaced with the current state.
+
+  The first run only writes the snapshot.
+
+Options:
+
+  -engine-hours-jump=<hours>  Increase of engine hours reported as a jump.
+                              Defaults to 24. Decreases are always reported.
+  -dry-run                    Don't update the snapshot.
+` + generalOptions + outputOptions)
+}
+
+func (c *FleetChangesCommand) Run(args []string) int {
+	opts := mykubota.FleetDiffOptions{}
+	fs := c.flagSet("fleet changes")
+	fs.Float64Var(&opts.EngineHoursJump, "engine-hours-jump", mykubota.DefaultEngineHoursJump, "")
+	dryRun := fs.Bool("dry-run", false, "")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one snapshot file\n\n%s", c.Help())
+	}
+	path := fs.Arg(0)
+
+	previous, err := mykubota.ReadFleetSnapshot(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c.errorf("%v", err)
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	fleet, err := session.Fleet(ctx)
+	if err != nil {
+		return c.errorf("unable to load fleet: %v", err)
+	}
+	current := fleet.Snapshot()
+
+	type changeRow struct {
+		mykubota.FleetChange
+		Change string `json:"change"`
+	}
+	rows := []changeRow{}
+	if previous == nil {
+		c.Ui.Warn(fmt.Sprintf("no snapshot at %s yet, recording %d machines", path, len(current.Equipment)))
+	} else {
+		for _, change := range mykubota.DiffFleetSnapshots(previous, current, opts).Changes {
+			rows = append(rows, changeRow{FleetChange: change, Change: change.String()})
+		}
+	}
+	if !*dryRun {
+		if err := current.WriteFile(path); err != nil {
+			return c.errorf("unable to write snapshot: %v", err)
+		}
+	}
+	return c.print(rows, "kind", "equipmentId", "change")
+}
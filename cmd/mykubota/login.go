@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+// LoginCommand authenticates with MyKubota and stores the resulting token
+type LoginCommand struct {
+	Meta
+}
+
+func (c *LoginCommand) Synopsis() string {
+	return "Log in to MyKubota"
+}
+
+func (c *LoginCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LoginCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-username":       complete.PredictAnything,
+		"-password-stdin": complete.PredictNothing,
+	})
+}
+
+func (c *LoginCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota login [options]
+
+  Authenticates the current profile with your MyKubota username and password.
+  The resulting token is stored in your user config directory, refreshed
+  automatically and used by all other commands. The profile is created if it
+  doesn't exist yet.
+
+Options:
+
+  -username=<email>  MyKubota username. Defaults to the username of the
+                     profile, and is prompted for if missing.
+  -password-stdin    Read the password from stdin. Otherwise $MYKUBOTA_PASSWORD
+                     is used, or the password is prompted for.
+` + generalOptions)
+}
+
+func (c *LoginCommand) Run(args []string) int {
+	var username string
+	var passwordStdin bool
+	fs := c.flagSet("login")
+	fs.StringVar(&username, "username", "", "")
+	fs.BoolVar(&passwordStdin, "password-stdin", false, "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	name := c.currentProfile()
+	profile := cfg.Profiles[name]
+	if username == "" {
+		username = profile.Username
+	}
+	if username == "" {
+		if username, err = c.Ui.Ask("Username:"); err != nil {
+			return c.errorf("unable to read username: %v", err)
+		}
+	}
+	password, err := c.readPassword(passwordStdin)
+	if err != nil {
+		return c.errorf("unable to read password: %v", err)
+	}
+
+	if err := c.login(name, username, password); err != nil {
+		return c.errorf("%v", err)
+	}
+	profile.Username = username
+	profile.Locale = c.currentLocale()
+	cfg.Profiles[name] = profile
+	if cfg.Current == "" {
+		cfg.Current = name
+	}
+	if err := c.saveConfig(cfg); err != nil {
+		return c.errorf("unable to save config: %v", err)
+	}
+	c.Ui.Info(fmt.Sprintf("Logged in as %s using profile %s", username, name))
+	return 0
+}
+
+// WhoamiCommand prints the authenticated user
+type WhoamiCommand struct {
+	Meta
+}
+
+func (c *WhoamiCommand) Synopsis() string {
+	return "Show the logged in user"
+}
+
+func (c *WhoamiCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *WhoamiCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *WhoamiCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota whoami [options]
+
+  Shows details about the user of the stored session.
+` + generalOptions + outputOptions)
+}
+
+func (c *WhoamiCommand) Run(args []string) int {
+	fs := c.flagSet("whoami")
+	c.outputFlags(fs, "json")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	user, err := session.User(ctx)
+	if err != nil {
+		return c.errorf("unable to fetch user: %v", err)
+	}
+	return c.print(user)
+}
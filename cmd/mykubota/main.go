@@ -0,0 +1,166 @@
+// Command mykubota gives terminal access to the MyKubota SDK
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mitchellh/cli"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	meta := Meta{
+		Ui: &cli.BasicUi{
+			Reader:      os.Stdin,
+			Writer:      os.Stdout,
+			ErrorWriter: os.Stderr,
+		},
+	}
+
+	c := cli.NewCLI("mykubota", version)
+	c.Args = args
+	c.Commands = commands(meta)
+
+	code, err := c.Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return code
+}
+
+func commands(meta Meta) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"login": func() (cli.Command, error) {
+			return &LoginCommand{Meta: meta}, nil
+		},
+		"whoami": func() (cli.Command, error) {
+			return &WhoamiCommand{Meta: meta}, nil
+		},
+
+		"profile": func() (cli.Command, error) {
+			return &groupCommand{synopsis: "Manage named accounts"}, nil
+		},
+		"profile add": func() (cli.Command, error) {
+			return &ProfileAddCommand{Meta: meta}, nil
+		},
+		"profile list": func() (cli.Command, error) {
+			return &ProfileListCommand{Meta: meta}, nil
+		},
+		"profile use": func() (cli.Command, error) {
+			return &ProfileUseCommand{Meta: meta}, nil
+		},
+		"profile remove": func() (cli.Command, error) {
+			return &ProfileRemoveCommand{Meta: meta}, nil
+		},
+
+		"equipment": func() (cli.Command, error) {
+			return &groupCommand{synopsis: "Manage equipment registered with your account"}, nil
+		},
+		"equipment list": func() (cli.Command, error) {
+			return &EquipmentListCommand{Meta: meta}, nil
+		},
+		"equipment get": func() (cli.Command, error) {
+			return &EquipmentGetCommand{Meta: meta}, nil
+		},
+		"equipment add": func() (cli.Command, error) {
+			return &EquipmentAddCommand{Meta: meta}, nil
+		},
+		"equipment update": func() (cli.Command, error) {
+			return &EquipmentUpdateCommand{Meta: meta}, nil
+		},
+		"equipment delete": func() (cli.Command, error) {
+			return &EquipmentDeleteCommand{Meta: meta}, nil
+		},
+
+		"maintenance": func() (cli.Command, error) {
+			return &groupCommand{synopsis: "Inspect and record maintenance"}, nil
+		},
+		"maintenance schedule": func() (cli.Command, error) {
+			return &MaintenanceScheduleCommand{Meta: meta}, nil
+		},
+		"maintenance history": func() (cli.Command, error) {
+			return &MaintenanceHistoryCommand{Meta: meta}, nil
+		},
+		"maintenance record": func() (cli.Command, error) {
+			return &MaintenanceRecordCommand{Meta: meta}, nil
+		},
+
+		"catalog": func() (cli.Command, error) {
+			return &groupCommand{synopsis: "Browse models and categories offered by Kubota"}, nil
+		},
+		"catalog models": func() (cli.Command, error) {
+			return &CatalogModelsCommand{Meta: meta}, nil
+		},
+		"catalog categories": func() (cli.Command, error) {
+			return &CatalogCategoriesCommand{Meta: meta}, nil
+		},
+		"catalog tree": func() (cli.Command, error) {
+			return &CatalogTreeCommand{Meta: meta}, nil
+		},
+		"catalog search": func() (cli.Command, error) {
+			return &CatalogSearchCommand{Meta: meta}, nil
+		},
+
+		"fleet": func() (cli.Command, error) {
+			return &groupCommand{synopsis: "Aggregated views of all your equipment"}, nil
+		},
+		"fleet summary": func() (cli.Command, error) {
+			return &FleetSummaryCommand{Meta: meta}, nil
+		},
+		"fleet changes": func() (cli.Command, error) {
+			return &FleetChangesCommand{Meta: meta}, nil
+		},
+
+		"manuals": func() (cli.Command, error) {
+			return &groupCommand{synopsis: "Keep manuals of your equipment offline"}, nil
+		},
+		"manuals sync": func() (cli.Command, error) {
+			return &ManualsSyncCommand{Meta: meta}, nil
+		},
+
+		"schema-check": func() (cli.Command, error) {
+			return &SchemaCheckCommand{Meta: meta}, nil
+		},
+
+		"settings": func() (cli.Command, error) {
+			return &groupCommand{
+				synopsis: "Show account settings; changing them isn't supported yet",
+				help: `Changing settings isn't supported yet, because the endpoint the MyKubota apps
+use to save them hasn't been confirmed. Change them in the app instead.`,
+			}, nil
+		},
+		"settings get": func() (cli.Command, error) {
+			return &SettingsGetCommand{Meta: meta}, nil
+		},
+	}
+}
+
+// groupCommand is the parent of a set of subcommands and only prints help
+type groupCommand struct {
+	synopsis string
+	// help is shown before the list of subcommands, e.g. to explain missing subcommands
+	help string
+}
+
+func (c *groupCommand) Help() string {
+	help := "This command is accessed by using one of the subcommands below."
+	if c.help != "" {
+		help = c.help + "\n\n" + help
+	}
+	return help
+}
+
+func (c *groupCommand) Synopsis() string {
+	return c.synopsis
+}
+
+func (c *groupCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
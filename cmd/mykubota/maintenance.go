@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/posener/complete"
+)
+
+// MaintenanceScheduleCommand shows the maintenance schedule of a model
+type MaintenanceScheduleCommand struct {
+	Meta
+}
+
+func (c *MaintenanceScheduleCommand) Synopsis() string {
+	return "Show the maintenance schedule of a model"
+}
+
+func (c *MaintenanceScheduleCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictModels()
+}
+
+func (c *MaintenanceScheduleCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *MaintenanceScheduleCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota maintenance schedule [options] <model>
+
+  Shows the maintenance schedule Kubota recommends for the given model.
+` + generalOptions + outputOptions)
+}
+
+func (c *MaintenanceScheduleCommand) Run(args []string) int {
+	fs := c.flagSet("maintenance schedule")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one model\n\n%s", c.Help())
+	}
+
+	schedule, err := c.client().MaintenanceSchedule(fs.Arg(0))
+	if err != nil {
+		return c.errorf("unable to fetch maintenance schedule: %v", err)
+	}
+	sort.SliceStable(schedule, func(i, j int) bool {
+		return schedule[i].SortOrder < schedule[j].SortOrder
+	})
+	return c.print(schedule, "id", "checkPoint", "measures", "intervalValue", "displayIntervalType", "firstCheckValue")
+}
+
+// MaintenanceHistoryCommand shows recorded maintenance of equipment
+type MaintenanceHistoryCommand struct {
+	Meta
+}
+
+func (c *MaintenanceHistoryCommand) Synopsis() string {
+	return "Show the maintenance history of equipment"
+}
+
+func (c *MaintenanceHistoryCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictEquipment()
+}
+
+func (c *MaintenanceHistoryCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *MaintenanceHistoryCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota maintenance history [options] <equipment>
+
+  Shows all maintenance recorded for the equipment. Equipment is identified
+  by its ID, nickname, PIN, serial number or model.
+` + generalOptions + outputOptions)
+}
+
+func (c *MaintenanceHistoryCommand) Run(args []string) int {
+	fs := c.flagSet("maintenance history")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one equipment\n\n%s", c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	eq, err := session.FindEquipment(ctx, fs.Arg(0))
+	if err != nil {
+		return c.errorf("unable to find equipment: %v", err)
+	}
+	history, err := session.MaintenanceHistory(eq.ID)
+	if err != nil {
+		return c.errorf("unable to fetch maintenance history: %v", err)
+	}
+	return c.print(history, "id", "updatedDate", "intervalType", "intervalValue", "completedEngineHours", "notes")
+}
+
+// MaintenanceRecordCommand records performed maintenance
+type MaintenanceRecordCommand struct {
+	Meta
+}
+
+func (c *MaintenanceRecordCommand) Synopsis() string {
+	return "Record performed maintenance"
+}
+
+func (c *MaintenanceRecordCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictEquipment()
+}
+
+func (c *MaintenanceRecordCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-interval-type":  complete.PredictAnything,
+		"-interval-value": complete.PredictAnything,
+		"-engine-hours":   complete.PredictAnything,
+		"-checks":         c.predictCheckPoints(),
+		"-notes":          complete.PredictAnything,
+	})
+}
+
+func (c *MaintenanceRecordCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota maintenance record [options] <equipment>
+
+  Adds an entry to the maintenance history of the equipment. Equipment is
+  identified by its ID, nickname, PIN, serial number or model.
+
+Options:
+
+  -interval-type=<type>    Interval type of the performed maintenance, e.g. Every X Hours.
+  -interval-value=<value>  Interval value of the performed maintenance, e.g. 250.
+  -engine-hours=<hours>    Engine hours at which the maintenance was completed.
+  -checks=<ids>            Comma separated maintenance schedule IDs which were performed.
+  -notes=<notes>           Free form notes.
+` + generalOptions)
+}
+
+func (c *MaintenanceRecordCommand) Run(args []string) int {
+	var intervalType, checks, notes string
+	var intervalValue int
+	var engineHours float64
+	fs := c.flagSet("maintenance record")
+	fs.StringVar(&intervalType, "interval-type", "", "")
+	fs.IntVar(&intervalValue, "interval-value", 0, "")
+	fs.Float64Var(&engineHours, "engine-hours", 0, "")
+	fs.StringVar(&checks, "checks", "", "")
+	fs.StringVar(&notes, "notes", "", "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one equipment\n\n%s", c.Help())
+	}
+
+	entry := mykubota.MaintenanceHistory{
+		IntervalType:         intervalType,
+		IntervalValue:        intervalValue,
+		CompletedEngineHours: float32(engineHours),
+		Notes:                notes,
+		UpdatedDate:          time.Now(),
+		MaintenanceCheckList: map[string]bool{},
+	}
+	for _, id := range strings.Split(checks, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			entry.MaintenanceCheckList[id] = true
+		}
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	eq, err := session.FindEquipment(ctx, fs.Arg(0))
+	if err != nil {
+		return c.errorf("unable to find equipment: %v", err)
+	}
+	if err := session.RecordMaintenance(eq.ID, entry); err != nil {
+		return c.errorf("unable to record maintenance: %v", err)
+	}
+	c.Ui.Info("Recorded maintenance for " + eq.ID)
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/posener/complete"
+)
+
+// ManualsSyncCommand downloads the manuals of all equipment into a local directory
+type ManualsSyncCommand struct {
+	Meta
+}
+
+func (c *ManualsSyncCommand) Synopsis() string {
+	return "Download manuals of your equipment for offline use"
+}
+
+func (c *ManualsSyncCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("*")
+}
+
+func (c *ManualsSyncCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *ManualsSyncCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota manuals sync [options] <dir>
+
+  Downloads the manuals of all equipment registered with your MyKubota
+  account into dir, laid out as <category>/<model>/<name>.pdf. An index of
+  all manuals and videos is written to dir/index.json.
+
+  Running sync again only downloads manuals which changed, repairs files
+  which don't match their checksum and resumes interrupted downloads.
+` + generalOptions + outputOptions)
+}
+
+func (c *ManualsSyncCommand) Run(args []string) int {
+	fs := c.flagSet("manuals sync")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one directory\n\n%s", c.Help())
+	}
+
+	ctx := context.Background()
+	client := c.client()
+	session, err := c.sessionFor(ctx, client)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	eqs, err := session.ListEquipment(ctx)
+	if err != nil {
+		return c.errorf("unable to list equipment: %v", err)
+	}
+
+	// equipment lists don't always include manuals, but the catalog does
+	var models map[string]mykubota.Model
+	for i, eq := range eqs {
+		if len(eq.ManualEntries) > 0 {
+			continue
+		}
+		if models == nil {
+			models = map[string]mykubota.Model{}
+			ms, err := client.ListModels(ctx)
+			if err != nil {
+				return c.errorf("unable to list models: %v", err)
+			}
+			for _, m := range ms {
+				models[m.Model] = m
+			}
+		}
+		eqs[i].ManualEntries = models[eq.Model].ManualEntries
+		if len(eq.VideoEntries) == 0 {
+			eqs[i].VideoEntries = models[eq.Model].VideoEntries
+		}
+	}
+
+	lib := &mykubota.ManualLibrary{
+		Dir: fs.Arg(0),
+		Progress: func(r mykubota.ManualSyncResult) {
+			if r.Status != mykubota.ManualUnchanged {
+				c.Ui.Warn(fmt.Sprintf("%s %s", r.Status, r.Manual.Path))
+			}
+		},
+	}
+	results, err := lib.Sync(ctx, eqs)
+	if err != nil {
+		return c.errorf("unable to sync manuals: %v", err)
+	}
+
+	type syncRow struct {
+		Path   string `json:"path"`
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+	rows := []syncRow{}
+	failed := 0
+	for _, r := range results {
+		row := syncRow{Path: r.Manual.Path, Status: string(r.Status)}
+		if r.Err != nil {
+			failed++
+			row.Error = r.Err.Error()
+		}
+		rows = append(rows, row)
+	}
+	if code := c.print(rows, "path", "status", "error"); code != 0 {
+		return code
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/output"
+)
+
+// Meta contains state and helpers shared by all commands
+type Meta struct {
+	Ui cli.Ui
+
+	locale   string
+	profile  string
+	output   string
+	columns  string
+	template string
+}
+
+const generalOptions = `
+General Options:
+
+  -profile=<name>   Profile to use. Defaults to $MYKUBOTA_PROFILE or the
+                    profile selected with profile use.
+  -locale=<locale>  Locale used to talk to MyKubota, e.g. en-US.
+                    Defaults to $MYKUBOTA_LOCALE or the locale of the profile.
+`
+
+const outputOptions = `
+Output Options:
+
+  -output=<format>    One of table, json, ndjson, csv or yaml.
+  -columns=<columns>  Comma separated fields to render, using JSON names.
+                      Nested fields are joined by dots, e.g. telematics.engineRunning.
+  -format=<template>  Go template rendered for every result, e.g. '{{ .ID }}'.
+                      Sprig functions are available.
+`
+
+func (m *Meta) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&m.locale, "locale", "", "")
+	fs.StringVar(&m.profile, "profile", "", "")
+	return fs
+}
+
+// currentProfile returns the name of the profile selected by flag, environment or config
+func (m *Meta) currentProfile() string {
+	if m.profile != "" {
+		return m.profile
+	}
+	if name := os.Getenv("MYKUBOTA_PROFILE"); name != "" {
+		return name
+	}
+	if cfg, err := m.loadConfig(); err == nil && cfg.Current != "" {
+		return cfg.Current
+	}
+	return defaultProfile
+}
+
+// currentLocale returns the locale selected by flag, environment or profile
+func (m *Meta) currentLocale() string {
+	if m.locale != "" {
+		return m.locale
+	}
+	if locale := os.Getenv("MYKUBOTA_LOCALE"); locale != "" {
+		return locale
+	}
+	if cfg, err := m.loadConfig(); err == nil {
+		if p, ok := cfg.Profiles[m.currentProfile()]; ok && p.Locale != "" {
+			return p.Locale
+		}
+	}
+	return defaultLocale
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (m *Meta) configDir() (string, error) {
+	if dir := os.Getenv("MYKUBOTA_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mykubota"), nil
+}
+
+func (m *Meta) cacheDir() (string, error) {
+	if dir := os.Getenv("MYKUBOTA_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mykubota"), nil
+}
+
+// client returns a client for public content, using an on-disk catalog cache
+func (m *Meta) client() *mykubota.Client {
+	opts := []mykubota.Option{}
+	if dir, err := m.cacheDir(); err == nil {
+		opts = append(opts, mykubota.WithCatalogCache(&mykubota.CatalogCache{
+			Dir: dir,
+			TTL: 24 * time.Hour,
+		}))
+	}
+	return mykubota.New(m.currentLocale(), opts...)
+}
+
+// tokenStore returns the token store of the given profile
+func (m *Meta) tokenStore(profile string) (*mykubota.FileTokenStore, error) {
+	dir, err := m.configDir()
+	if err != nil {
+		return nil, err
+	}
+	return &mykubota.FileTokenStore{Path: filepath.Join(dir, "tokens", profile+".json")}, nil
+}
+
+// session restores the session of the current profile. Refreshed tokens are persisted
+func (m *Meta) session(ctx context.Context) (*mykubota.Session, error) {
+	return m.sessionFor(ctx, m.client())
+}
+
+// sessionFor restores the session of the current profile using client
+func (m *Meta) sessionFor(ctx context.Context, client *mykubota.Client) (*mykubota.Session, error) {
+	profile := m.currentProfile()
+	store, err := m.tokenStore(profile)
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.SessionFromTokenStore(ctx, store)
+	if errors.Is(err, mykubota.ErrNoToken) {
+		return nil, fmt.Errorf("profile %q is not logged in, run `mykubota login -profile %s` first", profile, profile)
+	}
+	return session, err
+}
+
+func (m *Meta) errorf(format string, args ...any) int {
+	m.Ui.Error(fmt.Sprintf(format, args...))
+	return 1
+}
+
+// outputFlags registers the flags controlling how results are rendered
+func (m *Meta) outputFlags(fs *flag.FlagSet, defaultFormat string) {
+	fs.StringVar(&m.output, "output", defaultFormat, "")
+	fs.StringVar(&m.columns, "columns", "", "")
+	fs.StringVar(&m.template, "format", "", "")
+}
+
+// print renders v according to the output flags. defaultColumns are used
+// for tabular formats if no columns were selected
+func (m *Meta) print(v any, defaultColumns ...string) int {
+	opts := output.Options{
+		Format:   m.output,
+		Columns:  output.ParseColumns(m.columns),
+		Template: m.template,
+	}
+	if opts.Template != "" {
+		opts.Format = output.Template
+	}
+	if len(opts.Columns) == 0 && (opts.Format == output.Table || opts.Format == output.CSV) {
+		opts.Columns = defaultColumns
+	}
+
+	b := strings.Builder{}
+	if err := output.Write(&b, v, opts); err != nil {
+		return m.errorf("unable to render output: %v", err)
+	}
+	m.Ui.Output(strings.TrimSuffix(b.String(), "\n"))
+	return 0
+}
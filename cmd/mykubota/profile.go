@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/posener/complete"
+)
+
+const (
+	defaultProfile = "default"
+	defaultLocale  = "en-CA"
+)
+
+// Profile is a named MyKubota account
+type Profile struct {
+	Locale   string `json:"locale"`
+	Username string `json:"username,omitempty"`
+}
+
+// Config is persisted in the user config directory
+type Config struct {
+	Current  string             `json:"current,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+func (m *Meta) configPath() (string, error) {
+	dir, err := m.configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+func (m *Meta) loadConfig() (*Config, error) {
+	cfg := &Config{Profiles: map[string]Profile{}}
+	path, err := m.configPath()
+	if err != nil {
+		return nil, err
+	}
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, cfg); err != nil {
+		return nil, fmt.Errorf("unable to decode config %s: %v", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	return cfg, nil
+}
+
+func (m *Meta) saveConfig(cfg *Config) error {
+	path, err := m.configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	bs, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0600)
+}
+
+// readPassword reads the password from stdin if requested, $MYKUBOTA_PASSWORD, or prompts for it
+func (m *Meta) readPassword(fromStdin bool) (string, error) {
+	if fromStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("unable to read password from stdin: %v", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	if password := os.Getenv("MYKUBOTA_PASSWORD"); password != "" {
+		return password, nil
+	}
+	return m.Ui.AskSecret("Password:")
+}
+
+// login authenticates the profile and persists its token
+func (m *Meta) login(profile, username, password string) error {
+	session, err := m.client().Authenticate(context.Background(), username, password)
+	if err != nil {
+		return fmt.Errorf("login failed: %v", err)
+	}
+	store, err := m.tokenStore(profile)
+	if err != nil {
+		return err
+	}
+	if err := store.SetToken(session.Token); err != nil {
+		return fmt.Errorf("unable to store token: %v", err)
+	}
+	return nil
+}
+
+// predictProfiles completes configured profile names
+func (m *Meta) predictProfiles() complete.Predictor {
+	return complete.PredictFunc(func(args complete.Args) []string {
+		cfg, err := m.loadConfig()
+		if err != nil {
+			return nil
+		}
+		names := []string{}
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	})
+}
+
+// ProfileAddCommand creates a profile and logs it in
+type ProfileAddCommand struct {
+	Meta
+}
+
+func (c *ProfileAddCommand) Synopsis() string {
+	return "Add a profile"
+}
+
+func (c *ProfileAddCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *ProfileAddCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-username":       complete.PredictAnything,
+		"-password-stdin": complete.PredictNothing,
+	})
+}
+
+func (c *ProfileAddCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota profile add [options] <name>
+
+  Adds a named profile for a MyKubota account and logs it in. The first
+  profile becomes the current profile.
+
+Options:
+
+  -username=<email>  MyKubota username. Prompted for if missing.
+  -password-stdin    Read the password from stdin. Otherwise $MYKUBOTA_PASSWORD
+                     is used, or the password is prompted for.
+` + generalOptions)
+}
+
+func (c *ProfileAddCommand) Run(args []string) int {
+	var username string
+	var passwordStdin bool
+	fs := c.flagSet("profile add")
+	fs.StringVar(&username, "username", "", "")
+	fs.BoolVar(&passwordStdin, "password-stdin", false, "")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one profile name\n\n%s", c.Help())
+	}
+	name := fs.Arg(0)
+
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	if _, ok := cfg.Profiles[name]; ok {
+		return c.errorf("profile %q already exists", name)
+	}
+	if username == "" {
+		if username, err = c.Ui.Ask("Username:"); err != nil {
+			return c.errorf("unable to read username: %v", err)
+		}
+	}
+	password, err := c.readPassword(passwordStdin)
+	if err != nil {
+		return c.errorf("unable to read password: %v", err)
+	}
+
+	c.profile = name
+	locale := c.locale
+	if locale == "" {
+		locale = envOr("MYKUBOTA_LOCALE", defaultLocale)
+	}
+	c.locale = locale
+	if err := c.login(name, username, password); err != nil {
+		return c.errorf("%v", err)
+	}
+
+	cfg.Profiles[name] = Profile{Locale: locale, Username: username}
+	if cfg.Current == "" {
+		cfg.Current = name
+	}
+	if err := c.saveConfig(cfg); err != nil {
+		return c.errorf("unable to save config: %v", err)
+	}
+	c.Ui.Info(fmt.Sprintf("Added profile %s for %s (%s)", name, username, locale))
+	return 0
+}
+
+// ProfileListCommand lists all profiles
+type ProfileListCommand struct {
+	Meta
+}
+
+func (c *ProfileListCommand) Synopsis() string {
+	return "List profiles"
+}
+
+func (c *ProfileListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ProfileListCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *ProfileListCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota profile list [options]
+
+  Lists all profiles, including whether they are logged in.
+` + generalOptions + outputOptions)
+}
+
+func (c *ProfileListCommand) Run(args []string) int {
+	fs := c.flagSet("profile list")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	type profileStatus struct {
+		Name     string `json:"name"`
+		Current  bool   `json:"current"`
+		Locale   string `json:"locale"`
+		Username string `json:"username"`
+		Token    string `json:"token"`
+	}
+	names := []string{}
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	current := c.currentProfile()
+	statuses := []profileStatus{}
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		status := profileStatus{Name: name, Current: name == current, Locale: p.Locale, Username: p.Username, Token: "missing"}
+		if store, err := c.tokenStore(name); err == nil {
+			if t, err := store.Token(); err == nil {
+				status.Token = "valid"
+				if !t.Expiry.IsZero() && t.Expiry.Before(time.Now()) {
+					status.Token = "expired"
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return c.print(statuses, "name", "current", "locale", "username", "token")
+}
+
+// ProfileUseCommand selects the current profile
+type ProfileUseCommand struct {
+	Meta
+}
+
+func (c *ProfileUseCommand) Synopsis() string {
+	return "Select the current profile"
+}
+
+func (c *ProfileUseCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictProfiles()
+}
+
+func (c *ProfileUseCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *ProfileUseCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota profile use <name>
+
+  Selects the profile used by all commands unless -profile or
+  $MYKUBOTA_PROFILE are set.
+`)
+}
+
+func (c *ProfileUseCommand) Run(args []string) int {
+	fs := c.flagSet("profile use")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one profile name\n\n%s", c.Help())
+	}
+	name := fs.Arg(0)
+
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	if _, ok := cfg.Profiles[name]; !ok {
+		return c.errorf("unknown profile %q", name)
+	}
+	cfg.Current = name
+	if err := c.saveConfig(cfg); err != nil {
+		return c.errorf("unable to save config: %v", err)
+	}
+	c.Ui.Info("Using profile " + name)
+	return 0
+}
+
+// ProfileRemoveCommand deletes a profile and its token
+type ProfileRemoveCommand struct {
+	Meta
+}
+
+func (c *ProfileRemoveCommand) Synopsis() string {
+	return "Remove a profile"
+}
+
+func (c *ProfileRemoveCommand) AutocompleteArgs() complete.Predictor {
+	return c.predictProfiles()
+}
+
+func (c *ProfileRemoveCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+func (c *ProfileRemoveCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota profile remove <name>
+
+  Removes the profile and its stored token.
+`)
+}
+
+func (c *ProfileRemoveCommand) Run(args []string) int {
+	fs := c.flagSet("profile remove")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+	if fs.NArg() != 1 {
+		return c.errorf("expected exactly one profile name\n\n%s", c.Help())
+	}
+	name := fs.Arg(0)
+
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	if _, ok := cfg.Profiles[name]; !ok {
+		return c.errorf("unknown profile %q", name)
+	}
+	delete(cfg.Profiles, name)
+	if cfg.Current == name {
+		cfg.Current = ""
+	}
+	if err := c.saveConfig(cfg); err != nil {
+		return c.errorf("unable to save config: %v", err)
+	}
+	store, err := c.tokenStore(name)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	if err := os.Remove(store.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c.errorf("unable to remove token: %v", err)
+	}
+	c.Ui.Info("Removed profile " + name)
+	return 0
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/posener/complete"
+)
+
+// SchemaCheckCommand compares responses of every endpoint with the types of the SDK
+type SchemaCheckCommand struct {
+	Meta
+}
+
+func (c *SchemaCheckCommand) Synopsis() string {
+	return "Report API fields which are not mapped or missing"
+}
+
+func (c *SchemaCheckCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SchemaCheckCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, complete.Flags{
+		"-exit-code": complete.PredictNothing,
+	}, outputCompleteFlags)
+}
+
+func (c *SchemaCheckCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota schema-check [options]
+
+  Calls every read-only endpoint of the MyKubota API and reports fields
+  which are sent by the API but not mapped by the SDK, and mapped fields
+  which are missing from responses. Authenticated endpoints are skipped
+  if the profile is not logged in. Nothing is modified.
+
+Options:
+
+  -exit-code  Exit with code 2 if any drift was found.
+` + generalOptions + outputOptions)
+}
+
+func (c *SchemaCheckCommand) Run(args []string) int {
+	var exitCode bool
+	fs := c.flagSet("schema-check")
+	fs.BoolVar(&exitCode, "exit-code", false, "")
+	c.outputFlags(fs, "table")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	ctx := context.Background()
+	report := &mykubota.SchemaReport{}
+	client := mykubota.New(c.currentLocale(), mykubota.WithSchemaReport(report))
+	failed := 0
+	check := func(endpoint string, err error) {
+		if err != nil {
+			failed++
+			c.Ui.Warn(fmt.Sprintf("unable to check %s: %v", endpoint, err))
+		}
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return c.errorf("unable to list models: %v", err)
+	}
+	for _, model := range models {
+		if !model.HasMaintenanceSchedules {
+			continue
+		}
+		_, err := client.MaintenanceSchedule(model.Model)
+		check("maintenance schedule", err)
+		_, err = client.SearchMachine(ctx, mykubota.SearchMachineRequest{PartialModel: model.Model, Serial: "1"})
+		check("machine search", err)
+		break
+	}
+
+	if session, err := c.sessionFor(ctx, client); err != nil {
+		c.Ui.Warn(fmt.Sprintf("skipping authenticated endpoints: %v", err))
+	} else {
+		_, err := session.User(ctx)
+		check("user", err)
+		_, err = session.Settings(ctx)
+		check("settings", err)
+		eqs, err := session.ListEquipment(ctx)
+		check("equipment", err)
+		if len(eqs) > 0 {
+			_, err = session.GetEquipment(ctx, eqs[0].ID)
+			check("equipment details", err)
+			_, err = session.MaintenanceHistory(eqs[0].ID)
+			check("maintenance history", err)
+		}
+	}
+
+	type driftRow struct {
+		Endpoint string `json:"endpoint"`
+		Change   string `json:"change"`
+		Field    string `json:"field"`
+	}
+	rows := []driftRow{}
+	drifts := report.Drifts()
+	for _, d := range drifts {
+		for _, field := range d.Unknown {
+			rows = append(rows, driftRow{Endpoint: d.Endpoint, Change: "unknown", Field: field})
+		}
+		for _, field := range d.Missing {
+			rows = append(rows, driftRow{Endpoint: d.Endpoint, Change: "missing", Field: field})
+		}
+	}
+	if code := c.print(rows, "endpoint", "change", "field"); code != 0 {
+		return code
+	}
+	if failed > 0 {
+		return 1
+	}
+	if exitCode && len(drifts) > 0 {
+		return 2
+	}
+	return 0
+}
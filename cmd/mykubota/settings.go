@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+// SettingsGetCommand shows the account settings
+type SettingsGetCommand struct {
+	Meta
+}
+
+func (c *SettingsGetCommand) Synopsis() string {
+	return "Show account settings"
+}
+
+func (c *SettingsGetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SettingsGetCommand) AutocompleteFlags() complete.Flags {
+	return mergeFlags(generalFlags, outputCompleteFlags)
+}
+
+func (c *SettingsGetCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: mykubota settings get [options]
+
+  Shows the settings of your MyKubota account. Changing them isn't supported
+  yet, because the endpoint used to save settings hasn't been confirmed.
+` + generalOptions + outputOptions)
+}
+
+func (c *SettingsGetCommand) Run(args []string) int {
+	fs := c.flagSet("settings get")
+	c.outputFlags(fs, "json")
+	if err := fs.Parse(args); err != nil {
+		return c.errorf("%v\n\n%s", err, c.Help())
+	}
+
+	ctx := context.Background()
+	session, err := c.session(ctx)
+	if err != nil {
+		return c.errorf("%v", err)
+	}
+	settings, err := session.Settings(ctx)
+	if err != nil {
+		return c.errorf("unable to fetch settings: %v", err)
+	}
+	return c.print(settings)
+}
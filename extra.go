@@ -0,0 +1,191 @@
+package mykubota
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fieldNames caches the JSON names of struct fields by type
+var fieldNames sync.Map
+
+// jsonFieldNames returns the names of all fields encoding/json maps for the struct type t
+func jsonFieldNames(t reflect.Type) []string {
+	if names, ok := fieldNames.Load(t); ok {
+		return names.([]string)
+	}
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	fieldNames.Store(t, names)
+	return names
+}
+
+// knownFieldNames returns the lower cased JSON names of the fields of the struct type t.
+// encoding/json matches keys case-insensitively, so extra fields are compared the same way
+func knownFieldNames(t reflect.Type) map[string]bool {
+	known := map[string]bool{}
+	for _, name := range jsonFieldNames(t) {
+		known[strings.ToLower(name)] = true
+	}
+	return known
+}
+
+// unmarshalExtra decodes data into v, a pointer to a struct without custom unmarshaling,
+// and returns all fields of data which v doesn't map
+func unmarshalExtra(data []byte, v any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	extra := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, err
+	}
+	known := knownFieldNames(reflect.TypeOf(v).Elem())
+	for key := range extra {
+		if known[strings.ToLower(key)] {
+			delete(extra, key)
+		}
+	}
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
+}
+
+// marshalExtra encodes v, a struct without custom marshaling, and appends extra fields
+// sorted by name. Mapped fields take precedence over extra fields whose names only differ in case
+func marshalExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	bs, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return bs, err
+	}
+	known := knownFieldNames(reflect.TypeOf(v))
+	keys := []string{}
+	for key := range extra {
+		if !known[strings.ToLower(key)] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(bs[:len(bs)-1])
+	for i, key := range keys {
+		if i > 0 || len(bs) > 2 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value := extra[key]
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON keeps fields which are not mapped in Extra
+func (e *Equipment) UnmarshalJSON(data []byte) error {
+	type equipment Equipment
+	extra, err := unmarshalExtra(data, (*equipment)(e))
+	e.Extra = extra
+	return err
+}
+
+// MarshalJSON includes fields kept in Extra
+func (e Equipment) MarshalJSON() ([]byte, error) {
+	type equipment Equipment
+	return marshalExtra(equipment(e), e.Extra)
+}
+
+// UnmarshalJSON keeps fields which are not mapped in Extra
+func (t *EquipmentTelematics) UnmarshalJSON(data []byte) error {
+	type telematics EquipmentTelematics
+	extra, err := unmarshalExtra(data, (*telematics)(t))
+	t.Extra = extra
+	return err
+}
+
+// MarshalJSON includes fields kept in Extra
+func (t EquipmentTelematics) MarshalJSON() ([]byte, error) {
+	type telematics EquipmentTelematics
+	return marshalExtra(telematics(t), t.Extra)
+}
+
+// UnmarshalJSON keeps fields which are not mapped in Extra
+func (m *Model) UnmarshalJSON(data []byte) error {
+	type model Model
+	extra, err := unmarshalExtra(data, (*model)(m))
+	m.Extra = extra
+	return err
+}
+
+// MarshalJSON includes fields kept in Extra
+func (m Model) MarshalJSON() ([]byte, error) {
+	type model Model
+	return marshalExtra(model(m), m.Extra)
+}
+
+// UnmarshalJSON keeps fields which are not mapped in Extra
+func (c *Category) UnmarshalJSON(data []byte) error {
+	type category Category
+	extra, err := unmarshalExtra(data, (*category)(c))
+	c.Extra = extra
+	return err
+}
+
+// MarshalJSON includes fields kept in Extra
+func (c Category) MarshalJSON() ([]byte, error) {
+	type category Category
+	return marshalExtra(category(c), c.Extra)
+}
+
+// UnmarshalJSON keeps fields which are not mapped in Extra
+func (h *MaintenanceHistory) UnmarshalJSON(data []byte) error {
+	type history MaintenanceHistory
+	extra, err := unmarshalExtra(data, (*history)(h))
+	h.Extra = extra
+	return err
+}
+
+// MarshalJSON includes fields kept in Extra
+func (h MaintenanceHistory) MarshalJSON() ([]byte, error) {
+	type history MaintenanceHistory
+	return marshalExtra(history(h), h.Extra)
+}
+
+// UnmarshalJSON keeps fields which are not mapped in Extra
+func (r *UpdateEquipmentRequest) UnmarshalJSON(data []byte) error {
+	type request UpdateEquipmentRequest
+	extra, err := unmarshalExtra(data, (*request)(r))
+	r.Extra = extra
+	return err
+}
+
+// MarshalJSON includes fields kept in Extra
+func (r UpdateEquipmentRequest) MarshalJSON() ([]byte, error) {
+	type request UpdateEquipmentRequest
+	return marshalExtra(request(r), r.Extra)
+}
@@ -0,0 +1,102 @@
+package mykubota
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func assertRoundTrip(t *testing.T, data string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected, actual any
+	json.Unmarshal([]byte(data), &expected)
+	json.Unmarshal(bs, &actual)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("expected lossless round trip, got diff\n%s", diff)
+	}
+}
+
+func TestExtra_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	t.Run("equipment", func(t *testing.T) {
+		eq := Equipment{}
+		assertRoundTrip(t, `{
+			"id": "1", "model": "KX040-4", "categoryId": 2, "category": "Construction", "subcategory": "Excavators",
+			"identifierType": "Pin", "type": "machine", "pinOrSerial": "1", "pin": "1", "serial": "", "nickName": "Digger",
+			"userEnteredEngineHours": 12.5, "hasTelematics": true, "hasFaultCodes": false, "hasMaintenanceSchedules": true,
+			"manualEntries": [], "videoEntries": null, "dealerName": "Kubota Vancouver", "warrantyUrl": "https://example.com/warranty", "guideUrl": "",
+			"modelHeroUrl": "https://example.com/hero.png", "modelFullUrl": "", "modelIconUrl": "",
+			"telematics": {
+				"locationTime": "2022-07-01T12:00:00Z", "cumulativeOperatingHours": 1, "engineRunning": true,
+				"fuelTempCelsius": 1, "fuelRemainingPercent": 50, "defTempCelsius": 1, "defQualityPercent": 1,
+				"defRemainingPercent": 1, "defPressureKPascal": 1, "engineRPM": 1, "coolantTempCelsius": 1,
+				"hydraulicTempCelsius": 1, "extPowerVolts": 1, "airInletTempCelsius": 1, "ambientAirTempCelsius": 1,
+				"runNumber": 1, "motionState": "moving", "faultCodes": [], "insideGeofences": [],
+				"location": {"latitude": 1, "longitude": 2, "altitudeMeters": 3, "positionHeadingAngle": 4},
+				"restartInhibitStatus": {"canModify": false, "commandStatus": "", "equipmentStatus": ""},
+				"batteryVolts": {"min": 12.1, "max": 14.2}
+			}
+		}`, &eq)
+		if _, ok := eq.Extra["dealerName"]; !ok || len(eq.Extra) != 1 {
+			t.Fatalf("expected unmapped fields in Extra, got %v", eq.Extra)
+		}
+		if string(eq.Telematics.Extra["batteryVolts"]) != `{"min": 12.1, "max": 14.2}` {
+			t.Fatalf("expected nested unmapped fields in Extra, got %v", eq.Telematics.Extra)
+		}
+	})
+	t.Run("model", func(t *testing.T) {
+		assertRoundTrip(t, `{"categoryId": 1, "type": "machine", "compatibleAttachments": ["AP-1"], "hasFaultCodes": false,
+			"hasMaintenanceSchedules": true, "manualEntries": null, "videoEntries": null, "model": "KX040-4",
+			"guideUrl": "https://example.com/guide", "modelIconUrl": "https://example.com/icon.png", "modelHeroUrl": "", "modelFullUrl": "",
+			"categoryFullUrl": "", "categoryHeroUrl": "", "categoryIconUrl": "", "subcategoryFullUrl": "", "subcategoryHeroUrl": "", "subcategoryIconUrl": "", "warrantyUrl": ""}`, &Model{})
+	})
+	t.Run("category", func(t *testing.T) {
+		assertRoundTrip(t, `{"id": 2, "name": "Excavators", "parentId": 1, "heroUrl": "", "fullUrl": "", "iconUrl": "", "badge": "new"}`, &Category{})
+	})
+	t.Run("maintenance history", func(t *testing.T) {
+		assertRoundTrip(t, `{"id": "1", "intervalType": "Hours", "intervalValue": 250, "completedEngineHours": 251,
+			"notes": "", "updatedDate": "2022-05-02T09:30:00Z", "maintenanceCheckList": {"a": true}, "dealer": {"id": 7}}`, &MaintenanceHistory{})
+	})
+}
+
+func TestExtra_MappedFieldsTakePrecedence(t *testing.T) {
+	t.Parallel()
+
+	c := Category{ID: 1, Name: "Tractors", Extra: map[string]json.RawMessage{"name": json.RawMessage(`"stale"`), "ICONURL": json.RawMessage(`"stale"`), "badge": nil}}
+	bs, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"id":1,"name":"Tractors","parentId":null,"heroUrl":"","fullUrl":"","iconUrl":"","badge":null}`; string(bs) != expected {
+		t.Fatalf("expected %s, got %s", expected, bs)
+	}
+}
+
+func TestExtra_UpdateEquipmentRequest(t *testing.T) {
+	t.Parallel()
+
+	eq := Equipment{}
+	if err := json.Unmarshal([]byte(`{"id": "1", "nickName": "Digger", "NickName": "stale", "fleetNumber": 7}`), &eq); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]json.RawMessage{"fleetNumber": json.RawMessage(`7`)}, eq.Extra); diff != "" {
+		t.Fatalf("expected keys to be matched case-insensitively\n%s", diff)
+	}
+
+	bs, err := json.Marshal(UpdateEquipmentRequest{EquipmentID: eq.ID, NickName: "Big Digger", Extra: eq.Extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"id":"1","engineHours":0,"nickName":"Big Digger","fleetNumber":7}`; string(bs) != expected {
+		t.Fatalf("expected %s, got %s", expected, bs)
+	}
+}
@@ -0,0 +1,387 @@
+// Package filter selects SDK values with small boolean expressions, so bulk operations,
+// alert rules and the command line share one selector syntax, e.g.
+//
+//	category == "Tractors" && telematics.fuelRemainingPercent < 20 && hasTelematics
+//
+// Fields are addressed by their JSON names. Nested fields are joined by dots.
+// Expressions are type checked against the selected type when they are compiled:
+//
+//   - strings support == != contains startsWith endsWith, and matches (or =~) with a regular expression
+//   - numbers support == != < <= > >=
+//   - booleans support == != or can be used on their own, e.g. hasTelematics or !engineRunning
+//   - times support older and newer with a duration relative to now, e.g. telematics.locationTime older 2h,
+//     and comparisons with RFC 3339 timestamps or dates, e.g. telematics.locationTime >= "2024-01-31"
+//   - durations support comparisons with durations
+//   - len(field) compares the length of strings, lists and maps like a number
+//
+// Durations use Go syntax and may start with days, e.g. 90m, 1h30m or 7d.
+// Expressions are combined with &&, || and !, and grouped with parentheses
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter is a compiled expression matching values of T
+type Filter[T any] struct {
+	expr  string
+	match matcher
+
+	// Now returns the reference time of older and newer. Defaults to time.Now
+	Now func() time.Time
+}
+
+// Compile parses expr and checks it against the fields of T.
+// An empty expression matches everything
+func Compile[T any](expr string) (*Filter[T], error) {
+	f := &Filter[T]{expr: expr}
+	if strings.TrimSpace(expr) == "" {
+		f.match = func(reflect.Value, time.Time) bool { return true }
+		return f, nil
+	}
+	root, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiler{expr: expr, typ: reflect.TypeOf((*T)(nil)).Elem()}
+	if f.match, err = c.compile(root); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid
+func MustCompile[T any](expr string) *Filter[T] {
+	f, err := Compile[T](expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// String returns the source expression
+func (f *Filter[T]) String() string {
+	return f.expr
+}
+
+// Match reports whether v matches the expression
+func (f *Filter[T]) Match(v T) bool {
+	now := time.Now
+	if f.Now != nil {
+		now = f.Now
+	}
+	return f.match(reflect.ValueOf(&v).Elem(), now())
+}
+
+// Select returns all values matching the expression, in order
+func (f *Filter[T]) Select(vs []T) []T {
+	selected := []T{}
+	for _, v := range vs {
+		if f.Match(v) {
+			selected = append(selected, v)
+		}
+	}
+	return selected
+}
+
+type matcher func(v reflect.Value, now time.Time) bool
+
+type compiler struct {
+	expr string
+	typ  reflect.Type
+}
+
+func (c *compiler) errorf(t token, format string, args ...any) error {
+	return &Error{Expr: c.expr, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (c *compiler) compile(node ast) (matcher, error) {
+	switch node := node.(type) {
+	case *binaryAST:
+		left, err := c.compile(node.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := c.compile(node.right)
+		if err != nil {
+			return nil, err
+		}
+		if node.op == "&&" {
+			return func(v reflect.Value, now time.Time) bool { return left(v, now) && right(v, now) }, nil
+		}
+		return func(v reflect.Value, now time.Time) bool { return left(v, now) || right(v, now) }, nil
+	case *notAST:
+		operand, err := c.compile(node.operand)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value, now time.Time) bool { return !operand(v, now) }, nil
+	case *comparisonAST:
+		return c.compileComparison(node)
+	}
+	return nil, fmt.Errorf("filter: unexpected node %T", node)
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func (c *compiler) compileComparison(node *comparisonAST) (matcher, error) {
+	f, err := c.resolve(node.field)
+	if err != nil {
+		return nil, err
+	}
+	if node.length {
+		switch f.typ.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		default:
+			return nil, c.errorf(node.field, "len is not supported for %s of type %s", node.field.text, f.typ)
+		}
+		if !node.compared {
+			return nil, c.errorf(node.field, "len(%s) must be compared with a number", node.field.text)
+		}
+		return c.compileNumber(node, func(v reflect.Value) float64 {
+			return float64(f.get(v).Len())
+		})
+	}
+	if !node.compared {
+		if f.typ.Kind() != reflect.Bool {
+			return nil, c.errorf(node.field, "%s is a %s and must be compared with a value", node.field.text, f.typ)
+		}
+		return func(v reflect.Value, _ time.Time) bool { return f.get(v).Bool() }, nil
+	}
+
+	switch {
+	case f.typ == timeType:
+		return c.compileTime(node, f)
+	case f.typ == durationType:
+		if node.literal.kind != tokDuration {
+			return nil, c.errorf(node.literal, "%s must be compared with a duration", node.field.text)
+		}
+		return c.compileOrdered(node, float64(node.literal.dur), func(v reflect.Value) float64 {
+			return float64(f.get(v).Int())
+		})
+	}
+	switch f.typ.Kind() {
+	case reflect.String:
+		return c.compileString(node, f)
+	case reflect.Bool:
+		if node.op.text != "==" && node.op.text != "!=" {
+			break
+		}
+		if node.literal.kind != tokIdent {
+			return nil, c.errorf(node.literal, "%s must be compared with true or false", node.field.text)
+		}
+		expected := (node.literal.text == "true") == (node.op.text == "==")
+		return func(v reflect.Value, _ time.Time) bool { return f.get(v).Bool() == expected }, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return c.compileNumber(node, func(v reflect.Value) float64 { return float64(f.get(v).Int()) })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return c.compileNumber(node, func(v reflect.Value) float64 { return float64(f.get(v).Uint()) })
+	case reflect.Float32, reflect.Float64:
+		return c.compileNumber(node, func(v reflect.Value) float64 { return f.get(v).Float() })
+	default:
+		return nil, c.errorf(node.field, "%s of type %s can't be compared, only its len", node.field.text, f.typ)
+	}
+	return nil, c.errorf(node.op, "operator %s is not supported for %s of type %s", node.op.text, node.field.text, f.typ)
+}
+
+func (c *compiler) compileNumber(node *comparisonAST, value func(reflect.Value) float64) (matcher, error) {
+	if node.literal.kind != tokNumber {
+		return nil, c.errorf(node.literal, "%s must be compared with a number", node.field.text)
+	}
+	return c.compileOrdered(node, node.literal.num, value)
+}
+
+func (c *compiler) compileOrdered(node *comparisonAST, expected float64, value func(reflect.Value) float64) (matcher, error) {
+	cmp, ok := comparisons[node.op.text]
+	if !ok {
+		return nil, c.errorf(node.op, "operator %s is not supported for %s", node.op.text, node.field.text)
+	}
+	return func(v reflect.Value, _ time.Time) bool {
+		actual := value(v)
+		switch {
+		case actual < expected:
+			return cmp(-1)
+		case actual > expected:
+			return cmp(1)
+		}
+		return cmp(0)
+	}, nil
+}
+
+// comparisons map operators to the results of a three-way comparison they accept
+var comparisons = map[string]func(int) bool{
+	"==": func(c int) bool { return c == 0 },
+	"!=": func(c int) bool { return c != 0 },
+	"<":  func(c int) bool { return c < 0 },
+	"<=": func(c int) bool { return c <= 0 },
+	">":  func(c int) bool { return c > 0 },
+	">=": func(c int) bool { return c >= 0 },
+}
+
+func (c *compiler) compileString(node *comparisonAST, f field) (matcher, error) {
+	if node.literal.kind != tokString {
+		return nil, c.errorf(node.literal, "%s must be compared with a quoted string", node.field.text)
+	}
+	expected := node.literal.str
+	var match func(string) bool
+	switch node.op.text {
+	case "==":
+		match = func(s string) bool { return s == expected }
+	case "!=":
+		match = func(s string) bool { return s != expected }
+	case "contains":
+		match = func(s string) bool { return strings.Contains(s, expected) }
+	case "startsWith":
+		match = func(s string) bool { return strings.HasPrefix(s, expected) }
+	case "endsWith":
+		match = func(s string) bool { return strings.HasSuffix(s, expected) }
+	case "matches", "=~":
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return nil, c.errorf(node.literal, "invalid regular expression: %v", err)
+		}
+		match = re.MatchString
+	default:
+		return nil, c.errorf(node.op, "operator %s is not supported for strings", node.op.text)
+	}
+	return func(v reflect.Value, _ time.Time) bool { return match(f.get(v).String()) }, nil
+}
+
+func (c *compiler) compileTime(node *comparisonAST, f field) (matcher, error) {
+	switch node.op.text {
+	case "older", "newer":
+		if node.literal.kind != tokDuration {
+			return nil, c.errorf(node.literal, "%s must be followed by a duration like 2h or 7d", node.op.text)
+		}
+		d := node.literal.dur
+		older := node.op.text == "older"
+		// times which were never set are older than any duration
+		return func(v reflect.Value, now time.Time) bool {
+			t := f.get(v).Interface().(time.Time)
+			if t.IsZero() {
+				return older
+			}
+			if older {
+				return now.Sub(t) > d
+			}
+			return now.Sub(t) <= d
+		}, nil
+	}
+	cmp, ok := comparisons[node.op.text]
+	if !ok {
+		return nil, c.errorf(node.op, "operator %s is not supported for times", node.op.text)
+	}
+	if node.literal.kind != tokString {
+		return nil, c.errorf(node.literal, "%s must be compared with a quoted time like \"2024-01-31\"", node.field.text)
+	}
+	expected, err := time.Parse(time.RFC3339, node.literal.str)
+	if err != nil {
+		if expected, err = time.Parse("2006-01-02", node.literal.str); err != nil {
+			return nil, c.errorf(node.literal, "invalid time %s, expected RFC 3339 or YYYY-MM-DD", node.literal.text)
+		}
+	}
+	return func(v reflect.Value, _ time.Time) bool {
+		t := f.get(v).Interface().(time.Time)
+		switch {
+		case t.Before(expected):
+			return cmp(-1)
+		case t.After(expected):
+			return cmp(1)
+		}
+		return cmp(0)
+	}, nil
+}
+
+// field is a resolved path to a (nested) struct field
+type field struct {
+	index []int
+	typ   reflect.Type
+}
+
+// get returns the field of v, or the zero value if a pointer on the way is nil
+func (f field) get(v reflect.Value) reflect.Value {
+	for _, i := range f.index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(f.typ)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(f.typ)
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// resolve looks up a dotted path of JSON names. Exact names win over case-insensitive matches
+func (c *compiler) resolve(t token) (field, error) {
+	f := field{typ: c.typ}
+	for _, name := range strings.Split(t.text, ".") {
+		for f.typ.Kind() == reflect.Ptr {
+			f.typ = f.typ.Elem()
+		}
+		if f.typ.Kind() != reflect.Struct || f.typ == timeType {
+			return field{}, c.errorf(t, "%s has no field %q", t.text, name)
+		}
+		var match *reflect.StructField
+		for _, sf := range reflect.VisibleFields(f.typ) {
+			sf := sf
+			jsonName := jsonFieldName(sf)
+			if jsonName == "" {
+				continue
+			}
+			if jsonName == name {
+				match = &sf
+				break
+			}
+			if match == nil && strings.EqualFold(jsonName, name) {
+				match = &sf
+			}
+		}
+		if match == nil {
+			return field{}, c.errorf(t, "unknown field %q", t.text)
+		}
+		f.index = append(f.index, match.Index...)
+		f.typ = match.Type
+	}
+	for f.typ.Kind() == reflect.Ptr {
+		f.typ = f.typ.Elem()
+	}
+	return f, nil
+}
+
+// jsonFieldName returns the name encoding/json uses for sf, or an empty string if it's not encoded
+func jsonFieldName(sf reflect.StructField) string {
+	if !sf.IsExported() {
+		return ""
+	}
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		// fields of untagged embedded structs are promoted and visited on their own
+		t := sf.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if sf.Anonymous && t.Kind() == reflect.Struct {
+			return ""
+		}
+		name = sf.Name
+	}
+	return name
+}
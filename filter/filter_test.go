@@ -0,0 +1,145 @@
+package filter_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/filter"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func fleet() []mykubota.Equipment {
+	return []mykubota.Equipment{
+		{
+			ID: "1", Category: "Tractors", Model: "L2501", Nickname: "North Barn", HasTelematics: true,
+			Telematics: mykubota.EquipmentTelematics{
+				FuelRemainingPercent: 12,
+				LocationTime:         now.Add(-3 * time.Hour),
+				FaultCodes:           []interface{}{"P0101"},
+			},
+		},
+		{
+			ID: "2", Category: "Tractors", Model: "MX5400", Nickname: "South Barn", HasTelematics: true,
+			Telematics: mykubota.EquipmentTelematics{
+				FuelRemainingPercent: 80,
+				EngineRunning:        true,
+				LocationTime:         now.Add(-10 * time.Minute),
+			},
+		},
+		{ID: "3", Category: "Construction", Model: "KX040-4", Nickname: "Digger"},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	for expr, expected := range map[string]string{
+		``: "1,2,3",
+		`category == "Tractors" && telematics.fuelRemainingPercent < 20 && hasTelematics`: "1",
+		`hasTelematics && telematics.locationTime older 2h`:                               "1",
+		`telematics.locationTime newer 1h`:                                                "2",
+		`telematics.locationTime older 7d`:                                                "3",
+		`telematics.locationTime >= "2024-03-01"`:                                         "1,2",
+		`telematics.locationTime < "2024-03-01T11:00:00Z"`:                                "1,3",
+		`!telematics.engineRunning`:                                                       "1,3",
+		`telematics.engineRunning == false && hasTelematics`:                              "1",
+		`nickName contains "Barn" && !(model startsWith "MX")`:                            "1",
+		`model matches "^KX\\d+" || nickName endsWith "South Barn"`:                       "2,3",
+		`model =~ "(?i)^l"`:                                                               "1",
+		`len(telematics.faultCodes) > 0`:                                                  "1",
+		`len(nickName) == 6`:                                                              "3",
+		`category != "Tractors" || telematics.fuelRemainingPercent >= 80`:                 "2,3",
+		`telematics.location.latitude == 0 && id == "3"`:                                  "3",
+		`CATEGORY == "Construction"`:                                                      "3",
+	} {
+		f, err := filter.Compile[mykubota.Equipment](expr)
+		if err != nil {
+			t.Errorf("expected %q to compile, got %v", expr, err)
+			continue
+		}
+		f.Now = func() time.Time { return now }
+		ids := []string{}
+		for _, eq := range f.Select(fleet()) {
+			ids = append(ids, eq.ID)
+		}
+		if actual := strings.Join(ids, ","); actual != expected {
+			t.Errorf("expected %q to select %s, got %s", expr, expected, actual)
+		}
+	}
+}
+
+func TestFilter_pointers(t *testing.T) {
+	t.Parallel()
+
+	f := filter.MustCompile[*mykubota.Equipment](`telematics.fuelRemainingPercent < 20 && !hasTelematics`)
+	if !f.Match(&mykubota.Equipment{}) {
+		t.Fatalf("expected zero equipment to match")
+	}
+	if !f.Match(nil) {
+		t.Fatalf("expected nil to match like a zero value")
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	t.Parallel()
+
+	for expr, expected := range map[string]string{
+		`category == "Tractors" &&`:                `expected field, got "" at position 26`,
+		`categroy == "Tractors"`:                   `unknown field "categroy" at position 1`,
+		`telematics.fuel < 20`:                     `unknown field "telematics.fuel"`,
+		`category == Tractors`:                     `expected value, got "Tractors"`,
+		`category < "Tractors"`:                    `operator < is not supported for strings`,
+		`category == 20`:                           `category must be compared with a quoted string`,
+		`telematics.fuelRemainingPercent < "20"`:   `must be compared with a number`,
+		`telematics.fuelRemainingPercent < 2h`:     `must be compared with a number`,
+		`telematics.locationTime older 20`:         `older must be followed by a duration`,
+		`telematics.locationTime > "yesterday"`:    `invalid time "yesterday"`,
+		`model matches "("`:                        `invalid regular expression`,
+		`category`:                                 `category is a string and must be compared with a value`,
+		`len(hasTelematics) > 1`:                   `len is not supported for hasTelematics`,
+		`telematics.faultCodes == "P0101"`:         `can't be compared, only its len`,
+		`(hasTelematics`:                           `expected )`,
+		`hasTelematics = true`:                     `unknown operator "="`,
+		`category == "Tractors`:                    `unterminated string`,
+		`telematics.locationTime older 2x`:         `invalid number or duration "2x"`,
+		`hasTelematics == true hasFaultCodes`:      `unexpected "hasFaultCodes"`,
+		`extra == "x"`:                             `unknown field "extra"`,
+		`telematics.locationTime contains "2024"`:  `operator contains is not supported for times`,
+		`hasTelematics contains "true"`:            `operator contains is not supported for hasTelematics`,
+		`hasTelematics == "true"`:                  `must be compared with true or false`,
+		`telematics.engineRunning < true`:          `operator < is not supported for telematics.engineRunning`,
+		`telematics.location.latitude.degrees > 1`: `has no field "degrees"`,
+	} {
+		_, err := filter.Compile[mykubota.Equipment](expr)
+		filterErr := &filter.Error{}
+		if !errors.As(err, &filterErr) {
+			t.Errorf("expected %q to fail with a filter error, got %v", expr, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q to fail with %q, got %q", expr, expected, err)
+		}
+	}
+}
+
+func TestCompile_durations(t *testing.T) {
+	t.Parallel()
+
+	type job struct {
+		Runtime time.Duration `json:"runtime"`
+	}
+	f := filter.MustCompile[job](`runtime >= 1d12h && runtime < 90000m`)
+	for runtime, expected := range map[time.Duration]bool{
+		36 * time.Hour:      true,
+		35 * time.Hour:      false,
+		90000 * time.Minute: false,
+	} {
+		if actual := f.Match(job{Runtime: runtime}); actual != expected {
+			t.Errorf("expected %s to match %v, got %v", runtime, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	// value of number, string and duration literals
+	num float64
+	str string
+	dur time.Duration
+}
+
+// Error is returned for invalid expressions
+type Error struct {
+	Expr string
+	// Pos is the byte offset of the problem within Expr
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: %s at position %d of %q", e.Msg, e.Pos+1, e.Expr)
+}
+
+func lex(expr string) ([]token, error) {
+	tokens := []token{}
+	errorf := func(pos int, format string, args ...any) error {
+		return &Error{Expr: expr, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(expr) && expr[end] != '"'; end++ {
+				if expr[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(expr) {
+				return nil, errorf(i, "unterminated string")
+			}
+			s, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, errorf(i, "invalid string %s", expr[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokString, text: expr[i : end+1], pos: i, str: s})
+			i = end + 1
+		case strings.ContainsRune("=!<>&|", rune(c)):
+			op := expr[i : i+1]
+			if i+1 < len(expr) {
+				switch two := expr[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||", "=~":
+					op = two
+				}
+			}
+			switch op {
+			case "=", "&", "|":
+				return nil, errorf(i, "unknown operator %q", op)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(expr) && (isIdentChar(expr[end]) || expr[end] == '.') {
+				end++
+			}
+			text := expr[i:end]
+			if n, err := strconv.ParseFloat(text, 64); err == nil {
+				tokens = append(tokens, token{kind: tokNumber, text: text, pos: i, num: n})
+			} else if d, err := parseDuration(text); err == nil {
+				tokens = append(tokens, token{kind: tokDuration, text: text, pos: i, dur: d})
+			} else {
+				return nil, errorf(i, "invalid number or duration %q", text)
+			}
+			i = end
+		case isIdentChar(c):
+			end := i + 1
+			for end < len(expr) && (isIdentChar(expr[end]) || expr[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: expr[i:end], pos: i})
+			i = end
+		default:
+			return nil, errorf(i, "unexpected character %q", c)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(expr)}), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// parseDuration extends time.ParseDuration with days, e.g. 7d or 1d12h
+func parseDuration(s string) (time.Duration, error) {
+	days := time.Duration(0)
+	if idx := strings.IndexByte(s, 'd'); idx > 0 {
+		n, err := strconv.ParseFloat(s[:idx], 64)
+		if err != nil {
+			return 0, err
+		}
+		days = time.Duration(n * float64(24*time.Hour))
+		if s = s[idx+1:]; s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return days + d, err
+}
+
+// parser is a recursive descent parser for
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = operand [ operator literal ]
+//	operand    = field | "len" "(" field ")"
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+type ast interface{}
+
+type binaryAST struct {
+	op          string
+	left, right ast
+}
+
+type notAST struct {
+	operand ast
+}
+
+type comparisonAST struct {
+	field    token
+	length   bool
+	op       token
+	literal  token
+	compared bool
+}
+
+func parse(expr string) (ast, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expr: expr, tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Expr: p.expr, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (ast, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryAST{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (ast, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryAST{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (ast, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokOp && t.text == "!":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notAST{operand: operand}, nil
+	case t.kind == tokLParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected )")
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+// comparison operators, including keywords
+var operators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "=~": true,
+	"contains": true, "startsWith": true, "endsWith": true, "matches": true,
+	"older": true, "newer": true,
+}
+
+func (p *parser) parseComparison() (ast, error) {
+	node := &comparisonAST{}
+	t := p.next()
+	if t.kind != tokIdent {
+		return nil, p.errorf(t, "expected field, got %q", t.text)
+	}
+	if t.text == "len" && p.peek().kind == tokLParen {
+		p.next()
+		if t = p.next(); t.kind != tokIdent {
+			return nil, p.errorf(t, "expected field, got %q", t.text)
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected )")
+		}
+		node.length = true
+	}
+	node.field = t
+
+	op := p.peek()
+	if !((op.kind == tokOp || op.kind == tokIdent) && operators[op.text]) {
+		return node, nil
+	}
+	p.next()
+	literal := p.next()
+	switch literal.kind {
+	case tokString, tokNumber, tokDuration:
+	case tokIdent:
+		if literal.text != "true" && literal.text != "false" {
+			return nil, p.errorf(literal, "expected value, got %q; fields can only be compared with values", literal.text)
+		}
+	default:
+		return nil, p.errorf(literal, "expected value after %s", op.text)
+	}
+	node.op, node.literal, node.compared = op, literal, true
+	return node, nil
+}
@@ -0,0 +1,178 @@
+package mykubota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nicolai86/mykubota/pin"
+)
+
+// ErrEquipmentNotFound is returned by FindEquipment if no equipment matches the query
+var ErrEquipmentNotFound = errors.New("no matching equipment")
+
+// AmbiguousEquipmentError is returned by FindEquipment if a query matches multiple machines equally well
+type AmbiguousEquipmentError struct {
+	Query      string
+	Candidates []Equipment
+}
+
+func (e *AmbiguousEquipmentError) Error() string {
+	candidates := make([]string, 0, len(e.Candidates))
+	for _, eq := range e.Candidates {
+		candidates = append(candidates, describeEquipment(&eq))
+	}
+	return fmt.Sprintf("%q matches %d machines: %s", e.Query, len(e.Candidates), strings.Join(candidates, "; "))
+}
+
+func describeEquipment(eq *Equipment) string {
+	parts := []string{"id " + eq.ID, eq.Model}
+	if eq.PinOrSerial != "" {
+		parts = append(parts, eq.PinOrSerial)
+	}
+	if eq.Nickname == "" {
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprintf("%s (%s)", eq.Nickname, strings.Join(parts, ", "))
+}
+
+// equipmentMatch ranks how well equipment matches a query. Higher is better
+type equipmentMatch int
+
+const (
+	matchNone equipmentMatch = iota
+	matchFuzzyModel
+	matchFuzzyNickname
+	matchFuzzyIdentifier
+	matchModel
+	matchNickname
+	matchIdentifier
+	matchID
+)
+
+// FindEquipment returns the equipment identified by query, which may be an ID, nickname,
+// PIN, serial number or model. Exact matches win over fuzzy matches: nicknames containing the query
+// or with a typo, PINs and serial numbers ending with the query, and models starting with it.
+// Returns ErrEquipmentNotFound without match, and an *AmbiguousEquipmentError if the best
+// match isn't unique
+func (s *Session) FindEquipment(ctx context.Context, query string) (*Equipment, error) {
+	eqs, err := s.ListEquipment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findEquipment(eqs, query, matchFuzzyModel)
+}
+
+// FindEquipmentExact is like FindEquipment, but only accepts the whole ID, PIN, serial number or nickname.
+// Use it before destructive operations, where a typo must not select a different machine
+func (s *Session) FindEquipmentExact(ctx context.Context, query string) (*Equipment, error) {
+	eqs, err := s.ListEquipment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findEquipment(eqs, query, matchNickname)
+}
+
+// findEquipment returns the best match of at least minimum
+func findEquipment(eqs []Equipment, query string, minimum equipmentMatch) (*Equipment, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEquipmentNotFound
+	}
+	best := matchNone
+	candidates := []Equipment{}
+	for _, eq := range eqs {
+		m := matchEquipment(&eq, query)
+		if m < minimum || m < best {
+			continue
+		}
+		if m > best {
+			best = m
+			candidates = candidates[:0]
+		}
+		candidates = append(candidates, eq)
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w for %q", ErrEquipmentNotFound, query)
+	case 1:
+		return &candidates[0], nil
+	}
+	return nil, &AmbiguousEquipmentError{Query: query, Candidates: candidates}
+}
+
+func matchEquipment(eq *Equipment, query string) equipmentMatch {
+	if eq.ID == query {
+		return matchID
+	}
+	identifier := pin.Normalize(query)
+	identifiers := []string{}
+	for _, v := range []string{eq.PinOrSerial, eq.Pin, eq.Serial} {
+		if v = pin.Normalize(v); v != "" {
+			identifiers = append(identifiers, v)
+		}
+	}
+	for _, v := range identifiers {
+		if v == identifier {
+			return matchIdentifier
+		}
+	}
+	nickname, folded := normalizeNickname(eq.Nickname), normalizeNickname(query)
+	if nickname != "" && nickname == folded {
+		return matchNickname
+	}
+	model := normalizeModel(query)
+	if model != "" && normalizeModel(eq.Model) == model {
+		return matchModel
+	}
+
+	// operators often only read the last digits of a serial number
+	if len(identifier) >= 3 {
+		for _, v := range identifiers {
+			if strings.HasSuffix(v, identifier) {
+				return matchFuzzyIdentifier
+			}
+		}
+	}
+	if nickname != "" && (strings.Contains(nickname, folded) || (len(folded) >= 4 && levenshtein(nickname, folded) <= 2)) {
+		return matchFuzzyNickname
+	}
+	if model != "" && strings.HasPrefix(normalizeModel(eq.Model), model) {
+		return matchFuzzyModel
+	}
+	return matchNone
+}
+
+// normalizeNickname lower-cases s and collapses whitespace
+func normalizeNickname(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// levenshtein returns the edit distance of a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
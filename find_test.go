@@ -0,0 +1,92 @@
+package mykubota
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindEquipment(t *testing.T) {
+	t.Parallel()
+
+	eqs := []Equipment{
+		{ID: "1", Nickname: "North Barn BX", Model: "BX2380", PinOrSerial: "KBCDZ26CEN3K12345", Pin: "KBCDZ26CEN3K12345"},
+		{ID: "2", Nickname: "South Barn BX", Model: "BX2380", PinOrSerial: "30123", Serial: "30123"},
+		{ID: "3", Nickname: "Digger", Model: "KX040-4", PinOrSerial: "55555", Serial: "55555"},
+		{ID: "4", Model: "L2501", PinOrSerial: "30999", Serial: "30999"},
+	}
+
+	for query, expected := range map[string]string{
+		"3":                  "3",
+		"kbcdz26-cen3k12345": "1",
+		"30123":              "2",
+		"north  barn bx":     "1",
+		"l 2501":             "4",
+		"12345":              "1",
+		"diger":              "3",
+		"north":              "1",
+		"KX040":              "3",
+		"  South Barn BX   ": "2",
+	} {
+		eq, err := findEquipment(eqs, query, matchFuzzyModel)
+		if err != nil {
+			t.Errorf("expected %q to find %s, got %v", query, expected, err)
+			continue
+		}
+		if eq.ID != expected {
+			t.Errorf("expected %q to find %s, got %s", query, expected, eq.ID)
+		}
+	}
+
+	for query, expected := range map[string]int{"BX2380": 2, "barn": 2, "30": 0, "": 0, "excavator": 0} {
+		_, err := findEquipment(eqs, query, matchFuzzyModel)
+		ambiguous := &AmbiguousEquipmentError{}
+		switch {
+		case expected == 0 && !errors.Is(err, ErrEquipmentNotFound):
+			t.Errorf("expected %q to not be found, got %v", query, err)
+		case expected > 0 && (!errors.As(err, &ambiguous) || len(ambiguous.Candidates) != expected):
+			t.Errorf("expected %q to match %d machines, got %v", query, expected, err)
+		}
+	}
+
+	_, err := findEquipment(eqs, "barn", matchFuzzyModel)
+	if expected := `"barn" matches 2 machines: North Barn BX (id 1, BX2380, KBCDZ26CEN3K12345); South Barn BX (id 2, BX2380, 30123)`; err.Error() != expected {
+		t.Fatalf("expected %s, got %s", expected, err)
+	}
+}
+
+func TestFindEquipment_exact(t *testing.T) {
+	t.Parallel()
+
+	eqs := []Equipment{
+		{ID: "1", Nickname: "North Barn BX", Model: "BX2380", PinOrSerial: "KBCDZ26CEN3K12345", Pin: "KBCDZ26CEN3K12345"},
+		{ID: "3", Nickname: "Digger", Model: "KX040-4", PinOrSerial: "55555", Serial: "55555"},
+	}
+	for query, expected := range map[string]string{"3": "3", "kbcdz26-cen3k12345": "1", "north barn bx": "1", "Digger": "3"} {
+		if eq, err := findEquipment(eqs, query, matchNickname); err != nil || eq.ID != expected {
+			t.Errorf("expected %q to find %s, got %v", query, expected, err)
+		}
+	}
+	for _, query := range []string{"diger", "12345", "north", "KX040-4", "KX040"} {
+		if _, err := findEquipment(eqs, query, matchNickname); !errors.Is(err, ErrEquipmentNotFound) {
+			t.Errorf("expected %q to require an exact match, got %v", query, err)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"digger", "diger", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	} {
+		if d := levenshtein(tc.a, tc.b); d != tc.expected {
+			t.Errorf("expected distance %d between %q and %q, got %d", tc.expected, tc.a, tc.b, d)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package mykubota
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Default thresholds of a Fleet
+const (
+	DefaultLowFuelPercent = 20
+	DefaultLowDEFPercent  = 15
+	DefaultStaleAfter     = 24 * time.Hour
+)
+
+// Fleet provides aggregated views of all equipment of an account
+type Fleet struct {
+	Equipment []Equipment
+	// FetchedAt is the reference time of telematics ages
+	FetchedAt time.Time
+
+	// LowFuelPercent and LowDEFPercent are the levels below which machines count as low.
+	// Zero uses DefaultLowFuelPercent and DefaultLowDEFPercent
+	LowFuelPercent int
+	LowDEFPercent  float64
+	// StaleAfter is the age after which a telematics location counts as stale. Zero uses DefaultStaleAfter
+	StaleAfter time.Duration
+}
+
+// Fleet loads all equipment of the account
+func (s *Session) Fleet(ctx context.Context) (*Fleet, error) {
+	eqs, err := s.ListEquipment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Fleet{Equipment: eqs, FetchedAt: time.Now()}, nil
+}
+
+// FleetMachine is the status of a single machine within a FleetSummary
+type FleetMachine struct {
+	ID                   string    `json:"id"`
+	Nickname             string    `json:"nickName"`
+	Model                string    `json:"model"`
+	Category             string    `json:"category"`
+	EngineHours          float64   `json:"engineHours"`
+	EngineRunning        bool      `json:"engineRunning"`
+	FuelRemainingPercent int       `json:"fuelRemainingPercent"`
+	DEFRemainingPercent  float64   `json:"defRemainingPercent"`
+	FaultCodes           int       `json:"faultCodes"`
+	LocationTime         time.Time `json:"locationTime"`
+	// LocationAgeSeconds is the age of the location when the fleet was fetched
+	LocationAgeSeconds int64 `json:"locationAgeSeconds"`
+}
+
+// FleetFreshness describes how recent the telematics of the fleet are
+type FleetFreshness struct {
+	Fresh int `json:"fresh"`
+	// Unreported counts telematics enabled machines which never reported a location
+	Unreported int            `json:"unreported"`
+	Stale      []FleetMachine `json:"stale"`
+	Oldest     time.Time      `json:"oldest"`
+	Newest     time.Time      `json:"newest"`
+}
+
+// FleetSummary aggregates the status of a fleet for dashboards and reports
+type FleetSummary struct {
+	GeneratedAt         time.Time      `json:"generatedAt"`
+	Total               int            `json:"total"`
+	WithTelematics      int            `json:"withTelematics"`
+	ByCategory          map[string]int `json:"byCategory"`
+	ByModel             map[string]int `json:"byModel"`
+	TotalOperatingHours float64        `json:"totalOperatingHours"`
+	ActiveFaults        []FleetMachine `json:"activeFaults"`
+	LowFuel             []FleetMachine `json:"lowFuel"`
+	LowDEF              []FleetMachine `json:"lowDef"`
+	EngineRunning       []FleetMachine `json:"engineRunning"`
+	Freshness           FleetFreshness `json:"freshness"`
+}
+
+func (f *Fleet) lowFuelPercent() int {
+	if f.LowFuelPercent == 0 {
+		return DefaultLowFuelPercent
+	}
+	return f.LowFuelPercent
+}
+
+func (f *Fleet) lowDEFPercent() float64 {
+	if f.LowDEFPercent == 0 {
+		return DefaultLowDEFPercent
+	}
+	return f.LowDEFPercent
+}
+
+func (f *Fleet) staleAfter() time.Duration {
+	if f.StaleAfter == 0 {
+		return DefaultStaleAfter
+	}
+	return f.StaleAfter
+}
+
+func (f *Fleet) machine(eq *Equipment) FleetMachine {
+	m := FleetMachine{
+		ID:          eq.ID,
+		Nickname:    eq.Nickname,
+		Model:       eq.Model,
+		Category:    eq.Category,
+		EngineHours: eq.EngineHours(),
+	}
+	if eq.HasTelematics {
+		t := eq.Telematics
+		m.EngineRunning = t.EngineRunning
+		m.FuelRemainingPercent = t.FuelRemainingPercent
+		m.DEFRemainingPercent = t.DEFRemainingPercent
+		m.FaultCodes = len(t.FaultCodes)
+		m.LocationTime = t.LocationTime
+		if !t.LocationTime.IsZero() {
+			m.LocationAgeSeconds = int64(f.FetchedAt.Sub(t.LocationTime) / time.Second)
+		}
+	}
+	return m
+}
+
+// hasDEF reports whether the machine reports a diesel exhaust fluid system.
+// Machines without one report all DEF values as zero
+func hasDEF(t *EquipmentTelematics) bool {
+	return t.DEFRemainingPercent != 0 || t.DEFQualityPercent != 0 || t.DEFPressureKPascal != 0 || t.DEFTempCelsius != 0
+}
+
+// ByCategory counts machines by category
+func (f *Fleet) ByCategory() map[string]int {
+	res := map[string]int{}
+	for _, eq := range f.Equipment {
+		res[eq.Category]++
+	}
+	return res
+}
+
+// ByModel counts machines by model
+func (f *Fleet) ByModel() map[string]int {
+	res := map[string]int{}
+	for _, eq := range f.Equipment {
+		res[eq.Model]++
+	}
+	return res
+}
+
+// TotalOperatingHours sums the engine hours of all machines, see Equipment.EngineHours
+func (f *Fleet) TotalOperatingHours() float64 {
+	total := 0.0
+	for i := range f.Equipment {
+		total += f.Equipment[i].EngineHours()
+	}
+	return total
+}
+
+// filter returns all telematics enabled machines matching fn
+func (f *Fleet) filter(fn func(t *EquipmentTelematics) bool) []FleetMachine {
+	res := []FleetMachine{}
+	for i := range f.Equipment {
+		eq := &f.Equipment[i]
+		if eq.HasTelematics && fn(&eq.Telematics) {
+			res = append(res, f.machine(eq))
+		}
+	}
+	return res
+}
+
+// ActiveFaults returns machines reporting fault codes
+func (f *Fleet) ActiveFaults() []FleetMachine {
+	return f.filter(func(t *EquipmentTelematics) bool { return len(t.FaultCodes) > 0 })
+}
+
+// LowFuel returns machines with less fuel than LowFuelPercent, lowest first
+func (f *Fleet) LowFuel() []FleetMachine {
+	res := f.filter(func(t *EquipmentTelematics) bool { return t.FuelRemainingPercent < f.lowFuelPercent() })
+	sort.SliceStable(res, func(i, j int) bool { return res[i].FuelRemainingPercent < res[j].FuelRemainingPercent })
+	return res
+}
+
+// LowDEF returns machines with less diesel exhaust fluid than LowDEFPercent, lowest first.
+// Machines without a DEF system are ignored
+func (f *Fleet) LowDEF() []FleetMachine {
+	res := f.filter(func(t *EquipmentTelematics) bool { return hasDEF(t) && t.DEFRemainingPercent < f.lowDEFPercent() })
+	sort.SliceStable(res, func(i, j int) bool { return res[i].DEFRemainingPercent < res[j].DEFRemainingPercent })
+	return res
+}
+
+// EngineRunning returns machines with a running engine
+func (f *Fleet) EngineRunning() []FleetMachine {
+	return f.filter(func(t *EquipmentTelematics) bool { return t.EngineRunning })
+}
+
+// Freshness reports the age of telematics locations. Machines without telematics are ignored
+func (f *Fleet) Freshness() FleetFreshness {
+	res := FleetFreshness{Stale: []FleetMachine{}}
+	for i := range f.Equipment {
+		eq := &f.Equipment[i]
+		if !eq.HasTelematics {
+			continue
+		}
+		at := eq.Telematics.LocationTime
+		if at.IsZero() {
+			res.Unreported++
+			continue
+		}
+		if res.Oldest.IsZero() || at.Before(res.Oldest) {
+			res.Oldest = at
+		}
+		if at.After(res.Newest) {
+			res.Newest = at
+		}
+		if f.FetchedAt.Sub(at) > f.staleAfter() {
+			res.Stale = append(res.Stale, f.machine(eq))
+		} else {
+			res.Fresh++
+		}
+	}
+	sort.SliceStable(res.Stale, func(i, j int) bool { return res.Stale[i].LocationTime.Before(res.Stale[j].LocationTime) })
+	return res
+}
+
+// Summary returns all aggregated views of the fleet
+func (f *Fleet) Summary() FleetSummary {
+	withTelematics := 0
+	for _, eq := range f.Equipment {
+		if eq.HasTelematics {
+			withTelematics++
+		}
+	}
+	return FleetSummary{
+		GeneratedAt:         f.FetchedAt,
+		Total:               len(f.Equipment),
+		WithTelematics:      withTelematics,
+		ByCategory:          f.ByCategory(),
+		ByModel:             f.ByModel(),
+		TotalOperatingHours: f.TotalOperatingHours(),
+		ActiveFaults:        f.ActiveFaults(),
+		LowFuel:             f.LowFuel(),
+		LowDEF:              f.LowDEF(),
+		EngineRunning:       f.EngineRunning(),
+		Freshness:           f.Freshness(),
+	}
+}
@@ -0,0 +1,251 @@
+package mykubota
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// FleetSnapshot is a point in time copy of all equipment of an account, used to detect changes between runs
+type FleetSnapshot struct {
+	TakenAt   time.Time   `json:"takenAt"`
+	Equipment []Equipment `json:"equipment"`
+}
+
+// Snapshot returns a snapshot of the fleet, taken when it was fetched
+func (f *Fleet) Snapshot() *FleetSnapshot {
+	return &FleetSnapshot{TakenAt: f.FetchedAt, Equipment: f.Equipment}
+}
+
+// ReadFleetSnapshot reads a snapshot written by FleetSnapshot.WriteFile.
+// Errors wrap fs.ErrNotExist if there is no snapshot yet
+func ReadFleetSnapshot(path string) (*FleetSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &FleetSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("unable to decode fleet snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// WriteFile atomically replaces the snapshot at path
+func (s *FleetSnapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// FleetChangeKind is the type of a FleetChange
+type FleetChangeKind string
+
+const (
+	// EquipmentAdded means the equipment was registered with the account. New is the equipment
+	EquipmentAdded FleetChangeKind = "equipment-added"
+	// EquipmentRemoved means the equipment is no longer registered with the account. Old is the equipment
+	EquipmentRemoved FleetChangeKind = "equipment-removed"
+	// NicknameChanged means the equipment was renamed. Old and New are the nicknames
+	NicknameChanged FleetChangeKind = "nickname-changed"
+	// EngineHoursJumped means the engine hours went backwards, or increased by at least
+	// FleetDiffOptions.EngineHoursJump. Old and New are the engine hours
+	EngineHoursJumped FleetChangeKind = "engine-hours-jumped"
+	// FaultCodeAdded means the equipment reported a new fault code. New is the fault code
+	FaultCodeAdded FleetChangeKind = "fault-code-added"
+	// FaultCodeCleared means a fault code is no longer reported. Old is the fault code
+	FaultCodeCleared FleetChangeKind = "fault-code-cleared"
+	// GeofenceEntered means the equipment is inside a geofence it was outside of before. New is the geofence
+	GeofenceEntered FleetChangeKind = "geofence-entered"
+	// GeofenceLeft means the equipment left a geofence. Old is the geofence
+	GeofenceLeft FleetChangeKind = "geofence-left"
+	// RestartInhibitChanged means the restart inhibit status changed. Old and New are EquipmentRestartInhibitStatus
+	RestartInhibitChanged FleetChangeKind = "restart-inhibit-changed"
+)
+
+// DefaultEngineHoursJump is the increase of engine hours between two snapshots which is reported as a jump
+const DefaultEngineHoursJump = 24.0
+
+// FleetChange is a single change of a machine between two snapshots
+type FleetChange struct {
+	Kind        FleetChangeKind `json:"kind"`
+	EquipmentID string          `json:"equipmentId"`
+	Nickname    string          `json:"nickName"`
+	Model       string          `json:"model"`
+	Old         any             `json:"old,omitempty"`
+	New         any             `json:"new,omitempty"`
+}
+
+func (c FleetChange) String() string {
+	eq := &Equipment{ID: c.EquipmentID, Nickname: c.Nickname, Model: c.Model}
+	switch c.Kind {
+	case EquipmentAdded:
+		return fmt.Sprintf("%s was added", describeEquipment(eq))
+	case EquipmentRemoved:
+		return fmt.Sprintf("%s was removed", describeEquipment(eq))
+	case NicknameChanged:
+		return fmt.Sprintf("%s was renamed from %q to %q", describeEquipment(eq), c.Old, c.New)
+	case EngineHoursJumped:
+		return fmt.Sprintf("%s engine hours jumped from %v to %v", describeEquipment(eq), c.Old, c.New)
+	case FaultCodeAdded:
+		return fmt.Sprintf("%s reported fault %s", describeEquipment(eq), describeItem(c.New, "code"))
+	case FaultCodeCleared:
+		return fmt.Sprintf("%s cleared fault %s", describeEquipment(eq), describeItem(c.Old, "code"))
+	case GeofenceEntered:
+		return fmt.Sprintf("%s entered geofence %s", describeEquipment(eq), describeItem(c.New, "name"))
+	case GeofenceLeft:
+		return fmt.Sprintf("%s left geofence %s", describeEquipment(eq), describeItem(c.Old, "name"))
+	case RestartInhibitChanged:
+		old, _ := c.Old.(EquipmentRestartInhibitStatus)
+		new, _ := c.New.(EquipmentRestartInhibitStatus)
+		return fmt.Sprintf("%s restart inhibit changed from %q to %q", describeEquipment(eq), old.EquipmentStatus, new.EquipmentStatus)
+	}
+	return fmt.Sprintf("%s: %s", describeEquipment(eq), c.Kind)
+}
+
+// describeItem returns the field of a fault code or geofence, or its JSON encoding if it has no such field
+func describeItem(item any, field string) string {
+	if m, ok := item.(map[string]any); ok {
+		if v, ok := m[field]; ok {
+			return fmt.Sprint(v)
+		}
+	}
+	return itemKey(item)
+}
+
+// FleetDiffOptions configures DiffFleetSnapshots
+type FleetDiffOptions struct {
+	// EngineHoursJump is the increase of engine hours reported as EngineHoursJumped. Zero uses DefaultEngineHoursJump
+	EngineHoursJump float64
+}
+
+// FleetDiff is the difference between two fleet snapshots
+type FleetDiff struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Changes []FleetChange `json:"changes"`
+}
+
+// Empty reports whether no changes were detected
+func (d FleetDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// DiffFleetSnapshots compares two snapshots. Equipment is matched by its ID.
+// Fault codes and geofences are matched by their id field, or their whole content if they don't have one.
+// Changes are sorted by equipment ID, and by kind in the order of the FleetChangeKind constants.
+// A nil snapshot is treated like an account without equipment
+func DiffFleetSnapshots(old, new *FleetSnapshot, opts FleetDiffOptions) FleetDiff {
+	if old == nil {
+		old = &FleetSnapshot{}
+	}
+	if new == nil {
+		new = &FleetSnapshot{}
+	}
+	jump := opts.EngineHoursJump
+	if jump <= 0 {
+		jump = DefaultEngineHoursJump
+	}
+
+	diff := FleetDiff{From: old.TakenAt, To: new.TakenAt, Changes: []FleetChange{}}
+	oldByID := map[string]*Equipment{}
+	for i := range old.Equipment {
+		oldByID[old.Equipment[i].ID] = &old.Equipment[i]
+	}
+	newByID := map[string]*Equipment{}
+	for i := range new.Equipment {
+		newByID[new.Equipment[i].ID] = &new.Equipment[i]
+	}
+
+	for id, n := range newByID {
+		o, ok := oldByID[id]
+		if !ok {
+			diff.Changes = append(diff.Changes, newFleetChange(EquipmentAdded, n, nil, *n))
+			continue
+		}
+		diff.Changes = append(diff.Changes, diffEquipment(o, n, jump)...)
+	}
+	for id, o := range oldByID {
+		if _, ok := newByID[id]; !ok {
+			diff.Changes = append(diff.Changes, newFleetChange(EquipmentRemoved, o, *o, nil))
+		}
+	}
+	sort.SliceStable(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].EquipmentID < diff.Changes[j].EquipmentID
+	})
+	return diff
+}
+
+func newFleetChange(kind FleetChangeKind, eq *Equipment, old, new any) FleetChange {
+	return FleetChange{Kind: kind, EquipmentID: eq.ID, Nickname: eq.Nickname, Model: eq.Model, Old: old, New: new}
+}
+
+func diffEquipment(o, n *Equipment, jump float64) []FleetChange {
+	changes := []FleetChange{}
+	if o.Nickname != n.Nickname {
+		changes = append(changes, newFleetChange(NicknameChanged, n, o.Nickname, n.Nickname))
+	}
+	if oldHours, newHours := o.EngineHours(), n.EngineHours(); newHours < oldHours || newHours-oldHours >= jump {
+		changes = append(changes, newFleetChange(EngineHoursJumped, n, oldHours, newHours))
+	}
+	added, removed := diffItems(o.Telematics.FaultCodes, n.Telematics.FaultCodes)
+	for _, item := range added {
+		changes = append(changes, newFleetChange(FaultCodeAdded, n, nil, item))
+	}
+	for _, item := range removed {
+		changes = append(changes, newFleetChange(FaultCodeCleared, n, item, nil))
+	}
+	added, removed = diffItems(o.Telematics.InsideGeofences, n.Telematics.InsideGeofences)
+	for _, item := range added {
+		changes = append(changes, newFleetChange(GeofenceEntered, n, nil, item))
+	}
+	for _, item := range removed {
+		changes = append(changes, newFleetChange(GeofenceLeft, n, item, nil))
+	}
+	if o.Telematics.RestartInhibitStatus != n.Telematics.RestartInhibitStatus {
+		changes = append(changes, newFleetChange(RestartInhibitChanged, n, o.Telematics.RestartInhibitStatus, n.Telematics.RestartInhibitStatus))
+	}
+	return changes
+}
+
+// diffItems returns the items only present in new, and those only present in old, in their original order
+func diffItems(old, new []interface{}) (added, removed []interface{}) {
+	oldKeys := map[string]bool{}
+	for _, item := range old {
+		oldKeys[itemKey(item)] = true
+	}
+	newKeys := map[string]bool{}
+	for _, item := range new {
+		newKeys[itemKey(item)] = true
+	}
+	for _, item := range new {
+		if !oldKeys[itemKey(item)] {
+			added = append(added, item)
+		}
+	}
+	for _, item := range old {
+		if !newKeys[itemKey(item)] {
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+// itemKey identifies fault codes and geofences, whose structure isn't documented,
+// by their id or their JSON encoding, which has sorted keys
+func itemKey(item any) string {
+	if m, ok := item.(map[string]any); ok {
+		if id, ok := m["id"]; ok {
+			return fmt.Sprintf("id:%v", id)
+		}
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Sprint(item)
+	}
+	return string(data)
+}
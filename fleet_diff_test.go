@@ -0,0 +1,134 @@
+package mykubota
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffFleetSnapshots(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	overheated := map[string]any{"code": "P0217", "description": "Engine coolant over temperature"}
+	lowPressure := map[string]any{"code": "P0087", "description": "Fuel rail pressure too low"}
+	yard := map[string]any{"id": "g1", "name": "Yard", "radiusMeters": 100.0}
+	site := map[string]any{"id": "g2", "name": "Site"}
+	inhibited := EquipmentRestartInhibitStatus{CanModify: true, CommandStatus: "Success", EquipmentStatus: "RestartInhibited"}
+
+	old := &FleetSnapshot{
+		TakenAt: now.Add(-24 * time.Hour),
+		Equipment: []Equipment{
+			{ID: "1", Nickname: "Digger", Model: "KX040-4", HasTelematics: true, Telematics: EquipmentTelematics{
+				CumulativeOperatingHours: 100,
+				FaultCodes:               []interface{}{overheated},
+				InsideGeofences:          []interface{}{yard},
+			}},
+			{ID: "2", Nickname: "North Barn", Model: "L2501", UserEnteredEngineHours: 50},
+			{ID: "3", Model: "BX2380"},
+		},
+	}
+	new := &FleetSnapshot{
+		TakenAt: now,
+		Equipment: []Equipment{
+			{ID: "4", Model: "SVL75-2"},
+			{ID: "1", Nickname: "Big Digger", Model: "KX040-4", HasTelematics: true, Telematics: EquipmentTelematics{
+				CumulativeOperatingHours: 108,
+				FaultCodes:               []interface{}{lowPressure, overheated},
+				// geofences are matched by their id, so changed attributes don't count as leaving
+				InsideGeofences:      []interface{}{map[string]any{"id": "g1", "name": "Yard", "radiusMeters": 150.0}, site},
+				RestartInhibitStatus: inhibited,
+			}},
+			{ID: "2", Nickname: "North Barn", Model: "L2501", UserEnteredEngineHours: 20},
+		},
+	}
+
+	diff := DiffFleetSnapshots(old, new, FleetDiffOptions{})
+	expected := FleetDiff{
+		From: old.TakenAt,
+		To:   now,
+		Changes: []FleetChange{
+			{Kind: NicknameChanged, EquipmentID: "1", Nickname: "Big Digger", Model: "KX040-4", Old: "Digger", New: "Big Digger"},
+			{Kind: FaultCodeAdded, EquipmentID: "1", Nickname: "Big Digger", Model: "KX040-4", New: lowPressure},
+			{Kind: GeofenceEntered, EquipmentID: "1", Nickname: "Big Digger", Model: "KX040-4", New: site},
+			{Kind: RestartInhibitChanged, EquipmentID: "1", Nickname: "Big Digger", Model: "KX040-4", Old: EquipmentRestartInhibitStatus{}, New: inhibited},
+			{Kind: EngineHoursJumped, EquipmentID: "2", Nickname: "North Barn", Model: "L2501", Old: 50.0, New: 20.0},
+			{Kind: EquipmentRemoved, EquipmentID: "3", Model: "BX2380", Old: old.Equipment[2]},
+			{Kind: EquipmentAdded, EquipmentID: "4", Model: "SVL75-2", New: new.Equipment[0]},
+		},
+	}
+	if d := cmp.Diff(expected, diff); d != "" {
+		t.Fatalf("unexpected diff\n%s", d)
+	}
+
+	reverse := DiffFleetSnapshots(new, old, FleetDiffOptions{EngineHoursJump: 5})
+	kinds := []FleetChangeKind{}
+	for _, c := range reverse.Changes {
+		kinds = append(kinds, c.Kind)
+	}
+	expectedKinds := []FleetChangeKind{
+		NicknameChanged, EngineHoursJumped, FaultCodeCleared, GeofenceLeft, RestartInhibitChanged,
+		EngineHoursJumped, EquipmentAdded, EquipmentRemoved,
+	}
+	if d := cmp.Diff(expectedKinds, kinds); d != "" {
+		t.Fatalf("unexpected reverse changes\n%s", d)
+	}
+	if !DiffFleetSnapshots(new, new, FleetDiffOptions{}).Empty() {
+		t.Fatal("expected identical snapshots to produce an empty diff")
+	}
+	if len(DiffFleetSnapshots(nil, new, FleetDiffOptions{}).Changes) != 3 {
+		t.Fatal("expected all equipment to be added without a previous snapshot")
+	}
+}
+
+func TestFleetChange_String(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		change   FleetChange
+		expected string
+	}{
+		{FleetChange{Kind: EquipmentAdded, EquipmentID: "4", Model: "SVL75-2"}, "id 4, SVL75-2 was added"},
+		{FleetChange{Kind: NicknameChanged, EquipmentID: "1", Nickname: "Big Digger", Model: "KX040-4", Old: "Digger", New: "Big Digger"}, `Big Digger (id 1, KX040-4) was renamed from "Digger" to "Big Digger"`},
+		{FleetChange{Kind: FaultCodeAdded, EquipmentID: "1", Model: "KX040-4", New: map[string]any{"code": "P0087"}}, "id 1, KX040-4 reported fault P0087"},
+		{FleetChange{Kind: FaultCodeCleared, EquipmentID: "1", Model: "KX040-4", Old: "E9000"}, `id 1, KX040-4 cleared fault "E9000"`},
+		{FleetChange{Kind: GeofenceLeft, EquipmentID: "1", Model: "KX040-4", Old: map[string]any{"id": "g1", "name": "Yard"}}, "id 1, KX040-4 left geofence Yard"},
+	} {
+		if actual := tc.change.String(); actual != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestFleetSnapshot_WriteFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "fleet", "snapshot.json")
+	if _, err := ReadFleetSnapshot(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected missing snapshot to be reported, got %v", err)
+	}
+
+	fleet := &Fleet{
+		FetchedAt: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC),
+		Equipment: []Equipment{{ID: "1", Model: "KX040-4", HasTelematics: true, Telematics: EquipmentTelematics{
+			FaultCodes: []interface{}{map[string]any{"code": "P0217", "occurredAt": "2022-07-01T11:00:00Z"}},
+		}}},
+	}
+	if err := fleet.Snapshot().WriteFile(path); err != nil {
+		t.Fatalf("unable to write snapshot: %v", err)
+	}
+	snapshot, err := ReadFleetSnapshot(path)
+	if err != nil {
+		t.Fatalf("unable to read snapshot: %v", err)
+	}
+	if !snapshot.TakenAt.Equal(fleet.FetchedAt) || len(snapshot.Equipment) != 1 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	if diff := DiffFleetSnapshots(fleet.Snapshot(), snapshot, FleetDiffOptions{}); !diff.Empty() {
+		t.Fatalf("expected a snapshot to survive encoding, got %+v", diff.Changes)
+	}
+}
@@ -0,0 +1,71 @@
+package mykubota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFleet_Summary(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	fleet := &Fleet{
+		FetchedAt: now,
+		Equipment: []Equipment{
+			{ID: "1", Model: "KX040-4", Category: "Excavators", HasTelematics: true, Telematics: EquipmentTelematics{
+				LocationTime: now.Add(-time.Minute), CumulativeOperatingHours: 100, EngineRunning: true,
+				FuelRemainingPercent: 10, DEFRemainingPercent: 50, DEFQualityPercent: 99, FaultCodes: []interface{}{"E9000"},
+			}},
+			{ID: "2", Model: "KX040-4", Category: "Excavators", HasTelematics: true, UserEnteredEngineHours: 5, Telematics: EquipmentTelematics{
+				LocationTime: now.Add(-48 * time.Hour), FuelRemainingPercent: 80, DEFRemainingPercent: 5, DEFQualityPercent: 99,
+			}},
+			{ID: "3", Model: "L2501", Category: "Tractors", UserEnteredEngineHours: 20},
+			{ID: "4", Model: "SVL75-2", Category: "Track Loaders", HasTelematics: true, Telematics: EquipmentTelematics{FuelRemainingPercent: 50}},
+		},
+	}
+
+	summary := fleet.Summary()
+	if summary.Total != 4 || summary.WithTelematics != 3 || summary.TotalOperatingHours != 125 {
+		t.Fatalf("unexpected totals %+v", summary)
+	}
+	if diff := cmp.Diff(map[string]int{"Excavators": 2, "Tractors": 1, "Track Loaders": 1}, summary.ByCategory); diff != "" {
+		t.Fatalf("unexpected category counts\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int{"KX040-4": 2, "L2501": 1, "SVL75-2": 1}, summary.ByModel); diff != "" {
+		t.Fatalf("unexpected model counts\n%s", diff)
+	}
+
+	ids := func(ms []FleetMachine) []string {
+		res := []string{}
+		for _, m := range ms {
+			res = append(res, m.ID)
+		}
+		return res
+	}
+	for name, tc := range map[string]struct {
+		got, expected []string
+	}{
+		"faults":  {ids(summary.ActiveFaults), []string{"1"}},
+		"fuel":    {ids(summary.LowFuel), []string{"1"}},
+		"def":     {ids(summary.LowDEF), []string{"2"}},
+		"running": {ids(summary.EngineRunning), []string{"1"}},
+		"stale":   {ids(summary.Freshness.Stale), []string{"2"}},
+	} {
+		if diff := cmp.Diff(tc.expected, tc.got); diff != "" {
+			t.Errorf("unexpected %s machines\n%s", name, diff)
+		}
+	}
+	if f := summary.Freshness; f.Fresh != 1 || f.Unreported != 1 || !f.Newest.Equal(now.Add(-time.Minute)) || !f.Oldest.Equal(now.Add(-48*time.Hour)) {
+		t.Fatalf("unexpected freshness %+v", f)
+	}
+	if age := summary.Freshness.Stale[0].LocationAgeSeconds; age != 48*60*60 {
+		t.Fatalf("expected location age of 48h, got %ds", age)
+	}
+
+	fleet.LowFuelPercent = 90
+	if got := ids(fleet.LowFuel()); len(got) != 3 || got[0] != "1" {
+		t.Fatalf("expected custom threshold sorted by fuel level, got %v", got)
+	}
+}
@@ -3,16 +3,17 @@ module github.com/nicolai86/mykubota
 go 1.18
 
 require (
+	github.com/Masterminds/sprig/v3 v3.2.2
 	github.com/google/uuid v1.3.0
 	github.com/posener/complete v1.2.3
 	golang.org/x/oauth2 v0.0.0-20220630143837-2104d58473e0
 	golang.org/x/sync v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/Masterminds/goutils v1.1.1 // indirect
 	github.com/Masterminds/semver/v3 v3.1.1 // indirect
-	github.com/Masterminds/sprig/v3 v3.2.2 // indirect
 	github.com/armon/go-radix v1.0.0 // indirect
 	github.com/bgentry/speakeasy v0.1.0 // indirect
 	github.com/fatih/color v1.13.0 // indirect
@@ -0,0 +1,132 @@
+package mykubota
+
+import (
+	"sort"
+)
+
+// MaintenanceSnapshot maps model names to their maintenance schedule, as written by cmd/maintenance-cache
+type MaintenanceSnapshot map[string][]Maintenance
+
+// Kinds of changes reported by DiffMaintenanceSnapshots
+const (
+	MaintenanceAdded   = "added"
+	MaintenanceRemoved = "removed"
+	MaintenanceChanged = "changed"
+)
+
+// MaintenanceFieldChange describes a single modified field of a maintenance item
+type MaintenanceFieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// MaintenanceItemChange describes an added, removed or changed maintenance item of a model
+type MaintenanceItemChange struct {
+	ID         string                   `json:"id"`
+	CheckPoint string                   `json:"checkPoint"`
+	Kind       string                   `json:"kind"`
+	Changes    []MaintenanceFieldChange `json:"changes,omitempty"`
+}
+
+// MaintenanceModelDiff lists all item changes of a model present in both snapshots
+type MaintenanceModelDiff struct {
+	Model string                  `json:"model"`
+	Items []MaintenanceItemChange `json:"items"`
+}
+
+// MaintenanceDiff is the difference between two maintenance snapshots
+type MaintenanceDiff struct {
+	AddedModels   []string               `json:"addedModels"`
+	RemovedModels []string               `json:"removedModels"`
+	ChangedModels []MaintenanceModelDiff `json:"changedModels"`
+}
+
+// Empty reports whether both snapshots were equivalent
+func (d MaintenanceDiff) Empty() bool {
+	return len(d.AddedModels) == 0 && len(d.RemovedModels) == 0 && len(d.ChangedModels) == 0
+}
+
+// DiffMaintenanceSnapshots compares two snapshots. Maintenance items are matched by their ID,
+// and changes to CheckPoint, Measures, IntervalValue, FirstCheckValue and SortOrder are reported.
+// All lists are sorted by model name and item ID
+func DiffMaintenanceSnapshots(old, new MaintenanceSnapshot) MaintenanceDiff {
+	diff := MaintenanceDiff{
+		AddedModels:   []string{},
+		RemovedModels: []string{},
+		ChangedModels: []MaintenanceModelDiff{},
+	}
+	for model := range new {
+		if _, ok := old[model]; !ok {
+			diff.AddedModels = append(diff.AddedModels, model)
+		}
+	}
+	for model, oldSchedule := range old {
+		newSchedule, ok := new[model]
+		if !ok {
+			diff.RemovedModels = append(diff.RemovedModels, model)
+			continue
+		}
+		if items := diffMaintenanceSchedules(oldSchedule, newSchedule); len(items) > 0 {
+			diff.ChangedModels = append(diff.ChangedModels, MaintenanceModelDiff{Model: model, Items: items})
+		}
+	}
+	sort.Strings(diff.AddedModels)
+	sort.Strings(diff.RemovedModels)
+	sort.Slice(diff.ChangedModels, func(i, j int) bool {
+		return diff.ChangedModels[i].Model < diff.ChangedModels[j].Model
+	})
+	return diff
+}
+
+func diffMaintenanceSchedules(old, new []Maintenance) []MaintenanceItemChange {
+	oldByID := map[string]Maintenance{}
+	for _, m := range old {
+		oldByID[m.ID] = m
+	}
+	newByID := map[string]Maintenance{}
+	for _, m := range new {
+		newByID[m.ID] = m
+	}
+
+	items := []MaintenanceItemChange{}
+	for id, n := range newByID {
+		o, ok := oldByID[id]
+		if !ok {
+			items = append(items, MaintenanceItemChange{ID: id, CheckPoint: n.CheckPoint, Kind: MaintenanceAdded})
+			continue
+		}
+		if changes := diffMaintenance(o, n); len(changes) > 0 {
+			items = append(items, MaintenanceItemChange{ID: id, CheckPoint: n.CheckPoint, Kind: MaintenanceChanged, Changes: changes})
+		}
+	}
+	for id, o := range oldByID {
+		if _, ok := newByID[id]; !ok {
+			items = append(items, MaintenanceItemChange{ID: id, CheckPoint: o.CheckPoint, Kind: MaintenanceRemoved})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func diffMaintenance(o, n Maintenance) []MaintenanceFieldChange {
+	changes := []MaintenanceFieldChange{}
+	if o.CheckPoint != n.CheckPoint {
+		changes = append(changes, MaintenanceFieldChange{Field: "checkPoint", Old: o.CheckPoint, New: n.CheckPoint})
+	}
+	if o.Measures != n.Measures {
+		changes = append(changes, MaintenanceFieldChange{Field: "measures", Old: o.Measures, New: n.Measures})
+	}
+	if o.IntervalValue != n.IntervalValue {
+		changes = append(changes, MaintenanceFieldChange{Field: "intervalValue", Old: o.IntervalValue, New: n.IntervalValue})
+	}
+	if o.FirstCheckValue != n.FirstCheckValue {
+		changes = append(changes, MaintenanceFieldChange{Field: "firstCheckValue", Old: o.FirstCheckValue, New: n.FirstCheckValue})
+	}
+	if o.SortOrder != n.SortOrder {
+		changes = append(changes, MaintenanceFieldChange{Field: "sortOrder", Old: o.SortOrder, New: n.SortOrder})
+	}
+	return changes
+}
@@ -0,0 +1,60 @@
+package mykubota
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffMaintenanceSnapshots(t *testing.T) {
+	t.Parallel()
+
+	old := MaintenanceSnapshot{
+		"KX040-4": {
+			{ID: "1", CheckPoint: "Engine oil", Measures: "Change", IntervalValue: 500, FirstCheckValue: 50, SortOrder: 1},
+			{ID: "2", CheckPoint: "Fuel filter", Measures: "Replace", IntervalValue: 500, SortOrder: 2},
+		},
+		"SVL97-2": {
+			{ID: "3", CheckPoint: "Air filter", Measures: "Clean", IntervalValue: 100},
+		},
+		"L2501": {},
+	}
+	new := MaintenanceSnapshot{
+		"KX040-4": {
+			{ID: "1", CheckPoint: "Engine oil", Measures: "Replace", IntervalValue: 250, FirstCheckValue: 50, SortOrder: 1},
+			{ID: "4", CheckPoint: "Hydraulic oil", Measures: "Change", IntervalValue: 1000, SortOrder: 3},
+		},
+		"SVL97-2": {
+			{ID: "3", CheckPoint: "Air filter", Measures: "Clean", IntervalValue: 100},
+		},
+		"LX2610": {},
+	}
+
+	expected := MaintenanceDiff{
+		AddedModels:   []string{"LX2610"},
+		RemovedModels: []string{"L2501"},
+		ChangedModels: []MaintenanceModelDiff{
+			{
+				Model: "KX040-4",
+				Items: []MaintenanceItemChange{
+					{ID: "1", CheckPoint: "Engine oil", Kind: MaintenanceChanged, Changes: []MaintenanceFieldChange{
+						{Field: "measures", Old: "Change", New: "Replace"},
+						{Field: "intervalValue", Old: 500, New: 250},
+					}},
+					{ID: "2", CheckPoint: "Fuel filter", Kind: MaintenanceRemoved},
+					{ID: "4", CheckPoint: "Hydraulic oil", Kind: MaintenanceAdded},
+				},
+			},
+		},
+	}
+	diff := DiffMaintenanceSnapshots(old, new)
+	if d := cmp.Diff(expected, diff); d != "" {
+		t.Fatalf("unexpected diff\n%s", d)
+	}
+	if diff.Empty() {
+		t.Fatal("expected diff to be non-empty")
+	}
+	if !DiffMaintenanceSnapshots(new, new).Empty() {
+		t.Fatal("expected identical snapshots to produce an empty diff")
+	}
+}
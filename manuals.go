@@ -0,0 +1,428 @@
+package mykubota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ManualIndexFile is the name of the index a ManualLibrary writes into its directory
+const ManualIndexFile = "index.json"
+
+// ManualFile is a manual stored in a ManualLibrary
+type ManualFile struct {
+	Category string `json:"category"`
+	Model    string `json:"model"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	// Path of the file, relative to the library directory
+	Path         string    `json:"path"`
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	SyncedAt     time.Time `json:"syncedAt"`
+}
+
+// VideoLink is a video referenced by a model. Videos are streamed, so they are only indexed
+type VideoLink struct {
+	Category string `json:"category"`
+	Model    string `json:"model"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+}
+
+// ManualIndex lists the content of a ManualLibrary
+type ManualIndex struct {
+	UpdatedAt time.Time    `json:"updatedAt"`
+	Manuals   []ManualFile `json:"manuals"`
+	Videos    []VideoLink  `json:"videos"`
+}
+
+type ManualSyncStatus string
+
+const (
+	ManualDownloaded ManualSyncStatus = "downloaded"
+	ManualResumed    ManualSyncStatus = "resumed"
+	ManualUnchanged  ManualSyncStatus = "unchanged"
+	ManualFailed     ManualSyncStatus = "failed"
+)
+
+// ManualSyncResult is the outcome of syncing a single manual
+type ManualSyncResult struct {
+	Manual ManualFile
+	Status ManualSyncStatus
+	Err    error
+}
+
+// ManualLibrary mirrors the manuals of equipment into Dir, laid out as <category>/<model>/<name>.
+//
+// Syncing is resumable: interrupted downloads continue where they stopped if the server supports
+// range requests, and the index is updated after every manual. Files are verified against the checksums
+// of the index, and unchanged manuals are revalidated instead of downloaded again
+type ManualLibrary struct {
+	Dir string
+	// Client downloads manuals. Defaults to http.DefaultClient
+	Client *http.Client
+	// Progress is called after every manual, if set
+	Progress func(ManualSyncResult)
+}
+
+// Index returns the index of the library. A library which was never synced has an empty index
+func (l *ManualLibrary) Index() (*ManualIndex, error) {
+	bs, err := os.ReadFile(filepath.Join(l.Dir, ManualIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &ManualIndex{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := ManualIndex{}
+	if err := json.Unmarshal(bs, &index); err != nil {
+		return nil, fmt.Errorf("unable to read manual index: %w", err)
+	}
+	return &index, nil
+}
+
+// Sync downloads all manuals of eqs. Equipment sharing a model shares its manuals.
+// Failing manuals are reported in the results, and the returned error is only set if the sync
+// could not continue, e.g. because ctx was canceled or the index could not be written.
+// Manuals no longer referenced are removed from the index, but their files are kept
+func (l *ManualLibrary) Sync(ctx context.Context, eqs []Equipment) ([]ManualSyncResult, error) {
+	previous, err := l.Index()
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]ManualFile{}
+	for _, m := range previous.Manuals {
+		known[m.Path] = m
+	}
+
+	wanted, videos := l.plan(eqs)
+	index := &ManualIndex{Videos: videos}
+	results := []ManualSyncResult{}
+	for _, m := range wanted {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := l.syncManual(ctx, m, known)
+		if result.Status == ManualFailed {
+			// keep what was synced before, the file is still usable offline
+			if prev, ok := known[m.Path]; ok && prev.URL == m.URL {
+				index.Manuals = append(index.Manuals, prev)
+			}
+		} else {
+			index.Manuals = append(index.Manuals, result.Manual)
+			if err := l.writeIndex(index, known); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, result)
+		if l.Progress != nil {
+			l.Progress(result)
+		}
+	}
+	index.UpdatedAt = time.Now()
+	return results, l.writeIndex(index, nil)
+}
+
+// plan returns the manuals and videos of eqs, each assigned a unique path
+func (l *ManualLibrary) plan(eqs []Equipment) ([]ManualFile, []VideoLink) {
+	manuals := []ManualFile{}
+	videos := []VideoLink{}
+	seenURL := map[string]bool{}
+	seenPath := map[string]bool{}
+	for _, eq := range eqs {
+		category := eq.Category
+		if category == "" {
+			category = "Uncategorized"
+		}
+		for _, entry := range eq.ManualEntries {
+			if entry.URL == "" || seenURL[eq.Model+"\x00"+entry.URL] {
+				continue
+			}
+			seenURL[eq.Model+"\x00"+entry.URL] = true
+
+			ext := mediaExtension(entry.URL)
+			if ext == "" {
+				ext = ".pdf"
+			}
+			dir := filepath.Join(sanitizeFileName(category), sanitizeFileName(eq.Model))
+			base := sanitizeFileName(entry.Name)
+			rel := filepath.Join(dir, base+ext)
+			for i := 2; seenPath[rel]; i++ {
+				rel = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+			}
+			seenPath[rel] = true
+			manuals = append(manuals, ManualFile{
+				Category: category,
+				Model:    eq.Model,
+				Name:     entry.Name,
+				URL:      entry.URL,
+				Path:     filepath.ToSlash(rel),
+			})
+		}
+		for _, entry := range eq.VideoEntries {
+			if entry.URL == "" || seenURL[eq.Model+"\x00"+entry.URL] {
+				continue
+			}
+			seenURL[eq.Model+"\x00"+entry.URL] = true
+			videos = append(videos, VideoLink{Category: category, Model: eq.Model, Name: entry.Name, URL: entry.URL})
+		}
+	}
+	return manuals, videos
+}
+
+// writeIndex writes index, including entries of pending which are not part of it yet,
+// so an interrupted sync doesn't forget manuals it didn't get to
+func (l *ManualLibrary) writeIndex(index *ManualIndex, pending map[string]ManualFile) error {
+	out := *index
+	out.Manuals = append([]ManualFile{}, index.Manuals...)
+	synced := map[string]bool{}
+	for _, m := range index.Manuals {
+		synced[m.Path] = true
+	}
+	for path, m := range pending {
+		if !synced[path] {
+			out.Manuals = append(out.Manuals, m)
+		}
+	}
+	sort.Slice(out.Manuals, func(i, j int) bool { return out.Manuals[i].Path < out.Manuals[j].Path })
+	bs, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(l.Dir, ManualIndexFile), bs)
+}
+
+func (l *ManualLibrary) syncManual(ctx context.Context, m ManualFile, known map[string]ManualFile) ManualSyncResult {
+	target := filepath.Join(l.Dir, filepath.FromSlash(m.Path))
+	var prev *ManualFile
+	if p, ok := known[m.Path]; ok && p.URL == m.URL {
+		if sum, size, err := hashFile(target); err == nil && sum == p.SHA256 && size == p.Size {
+			prev = &p
+		}
+	}
+
+	status, err := l.download(ctx, m.URL, target, prev)
+	if err != nil {
+		return ManualSyncResult{Manual: m, Status: ManualFailed, Err: err}
+	}
+	if status == ManualUnchanged {
+		m.SHA256, m.Size, m.ETag, m.LastModified = prev.SHA256, prev.Size, prev.ETag, prev.LastModified
+		m.SyncedAt = time.Now()
+		return ManualSyncResult{Manual: m, Status: status}
+	}
+
+	meta, err := readPartMeta(target)
+	if err != nil {
+		return ManualSyncResult{Manual: m, Status: ManualFailed, Err: err}
+	}
+	sum, size, err := hashFile(target + ".part")
+	if err != nil {
+		return ManualSyncResult{Manual: m, Status: ManualFailed, Err: err}
+	}
+	if meta.Size >= 0 && size != meta.Size {
+		os.Remove(target + ".part")
+		os.Remove(target + ".part.json")
+		return ManualSyncResult{Manual: m, Status: ManualFailed, Err: fmt.Errorf("incomplete download: expected %d bytes, got %d", meta.Size, size)}
+	}
+	if err := os.Rename(target+".part", target); err != nil {
+		return ManualSyncResult{Manual: m, Status: ManualFailed, Err: err}
+	}
+	os.Remove(target + ".part.json")
+	if prev != nil && prev.SHA256 == sum {
+		status = ManualUnchanged
+	}
+	m.SHA256, m.Size, m.ETag, m.LastModified = sum, size, meta.ETag, meta.LastModified
+	m.SyncedAt = time.Now()
+	return ManualSyncResult{Manual: m, Status: status}
+}
+
+// partMeta describes an incomplete download, stored next to it as <file>.part.json
+type partMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Size of the complete file, or -1 if unknown
+	Size int64 `json:"size"`
+}
+
+func readPartMeta(target string) (*partMeta, error) {
+	bs, err := os.ReadFile(target + ".part.json")
+	if err != nil {
+		return nil, err
+	}
+	meta := partMeta{}
+	return &meta, json.Unmarshal(bs, &meta)
+}
+
+// download fetches url into target.part. It returns ManualUnchanged if prev is still current,
+// and resumes a previous incomplete download if the server supports range requests
+func (l *ManualLibrary) download(ctx context.Context, url, target string, prev *ManualFile) (ManualSyncStatus, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	partial := int64(0)
+	if meta, err := readPartMeta(target); err == nil && meta.URL == url {
+		if fi, err := os.Stat(target + ".part"); err == nil && fi.Size() > 0 {
+			validator := meta.ETag
+			if validator == "" {
+				validator = meta.LastModified
+			}
+			if validator != "" {
+				partial = fi.Size()
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-", partial))
+				req.Header.Set("If-Range", validator)
+			}
+		}
+	}
+	if partial == 0 && prev != nil {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, []int{http.StatusOK, http.StatusPartialContent, http.StatusNotModified}); err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		if prev == nil {
+			return "", fmt.Errorf("received unexpected not modified response for %s", url)
+		}
+		return ManualUnchanged, nil
+	}
+
+	status := ManualDownloaded
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	size := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return "", err
+		}
+		if start != partial {
+			return "", fmt.Errorf("server resumed at byte %d instead of %d", start, partial)
+		}
+		status, flags, size = ManualResumed, os.O_WRONLY|os.O_APPEND, total
+	} else {
+		meta, err := json.Marshal(partMeta{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Size:         size,
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := writeFileAtomic(target+".part.json", meta); err != nil {
+			return "", err
+		}
+	}
+	if size >= 0 && status == ManualResumed {
+		meta, err := readPartMeta(target)
+		if err != nil {
+			return "", err
+		}
+		meta.Size = size
+		bs, err := json.Marshal(meta)
+		if err != nil {
+			return "", err
+		}
+		if err := writeFileAtomic(target+".part.json", bs); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.OpenFile(target+".part", flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	return status, f.Close()
+}
+
+// parseContentRange parses a header like "bytes 100-199/200". The total is -1 if unknown
+func parseContentRange(header string) (start, total int64, err error) {
+	if !strings.HasPrefix(header, "bytes ") {
+		return 0, 0, fmt.Errorf("unsupported content range %q", header)
+	}
+	rng, size, ok := strings.Cut(strings.TrimPrefix(header, "bytes "), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported content range %q", header)
+	}
+	first, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported content range %q", header)
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("unsupported content range %q", header)
+	}
+	if size == "*" {
+		return start, -1, nil
+	}
+	if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("unsupported content range %q", header)
+	}
+	return start, total, nil
+}
+
+// hashFile returns the hex encoded SHA-256 and size of the file at path
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// sanitizeFileName turns name into a file name which is valid on all common file systems
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 32:
+			return -1
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '-'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
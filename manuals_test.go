@@ -0,0 +1,208 @@
+package mykubota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManualLibrary_Sync(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"/kx040-operator.pdf": bytes.Repeat([]byte("operator "), 100),
+		"/kx040-safety.pdf":   []byte("safety"),
+		"/l2501-operator":     []byte("tractor"),
+	}
+	var mu sync.Mutex
+	requests := []*http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"`+r.URL.Path+`"`)
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+	take := func() []*http.Request {
+		mu.Lock()
+		defer mu.Unlock()
+		rs := requests
+		requests = nil
+		return rs
+	}
+
+	eqs := []Equipment{
+		{Model: "KX040-4", Category: "Construction", ManualEntries: ManualEntries{
+			{Name: "Operator's Manual", URL: srv.URL + "/kx040-operator.pdf"},
+			{Name: "Safety", URL: srv.URL + "/kx040-safety.pdf"},
+		}, VideoEntries: VideoEntries{{Name: "Walkaround", URL: "https://example.com/video"}}},
+		{Model: "KX040-4", Category: "Construction", ManualEntries: ManualEntries{
+			{Name: "Operator's Manual", URL: srv.URL + "/kx040-operator.pdf"},
+		}},
+		{Model: "L2501", Category: "Tractors", ManualEntries: ManualEntries{
+			{Name: "Operator: L2501", URL: srv.URL + "/l2501-operator"},
+		}},
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	lib := &ManualLibrary{Dir: dir}
+
+	statuses := func(results []ManualSyncResult) map[string]ManualSyncStatus {
+		out := map[string]ManualSyncStatus{}
+		for _, r := range results {
+			if r.Err != nil && r.Status != ManualFailed {
+				t.Fatalf("unexpected error for %s: %v", r.Manual.Path, r.Err)
+			}
+			out[r.Manual.Path] = r.Status
+		}
+		return out
+	}
+
+	t.Run("initial sync", func(t *testing.T) {
+		results, err := lib.Sync(ctx, eqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := statuses(results)
+		expected := map[string]ManualSyncStatus{
+			"Construction/KX040-4/Operator's Manual.pdf": ManualDownloaded,
+			"Construction/KX040-4/Safety.pdf":            ManualDownloaded,
+			"Tractors/L2501/Operator- L2501.pdf":         ManualDownloaded,
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		for path, status := range expected {
+			if got[path] != status {
+				t.Fatalf("expected %s to be %s, got %v", path, status, got)
+			}
+		}
+		if n := len(take()); n != 3 {
+			t.Fatalf("expected shared manuals to be downloaded once, got %d requests", n)
+		}
+		bs, err := os.ReadFile(filepath.Join(dir, "Tractors", "L2501", "Operator- L2501.pdf"))
+		if err != nil || string(bs) != "tractor" {
+			t.Fatalf("expected manual on disk, got %q: %v", bs, err)
+		}
+
+		index, err := lib.Index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(index.Manuals) != 3 || len(index.Videos) != 1 || index.Manuals[0].SHA256 == "" {
+			t.Fatalf("unexpected index %+v", index)
+		}
+	})
+
+	t.Run("unchanged manuals are skipped", func(t *testing.T) {
+		results, err := lib.Sync(ctx, eqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for path, status := range statuses(results) {
+			if status != ManualUnchanged {
+				t.Fatalf("expected %s to be unchanged, got %s", path, status)
+			}
+		}
+		for _, r := range take() {
+			if r.Header.Get("If-None-Match") == "" {
+				t.Fatalf("expected conditional request for %s", r.URL.Path)
+			}
+		}
+	})
+
+	t.Run("corrupted manuals are downloaded again", func(t *testing.T) {
+		path := filepath.Join(dir, "Construction", "KX040-4", "Safety.pdf")
+		if err := os.WriteFile(path, []byte("safetx"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		results, err := lib.Sync(ctx, eqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := statuses(results)["Construction/KX040-4/Safety.pdf"]; status != ManualDownloaded {
+			t.Fatalf("expected corrupted manual to be downloaded, got %s", status)
+		}
+		if bs, _ := os.ReadFile(path); string(bs) != "safety" {
+			t.Fatalf("expected repaired manual, got %q", bs)
+		}
+		take()
+	})
+
+	t.Run("interrupted downloads are resumed", func(t *testing.T) {
+		target := filepath.Join(dir, "Construction", "KX040-4", "Operator's Manual.pdf")
+		content := files["/kx040-operator.pdf"]
+		os.Remove(target)
+		if err := os.WriteFile(target+".part", content[:300], 0644); err != nil {
+			t.Fatal(err)
+		}
+		meta, _ := json.Marshal(partMeta{URL: srv.URL + "/kx040-operator.pdf", ETag: `"/kx040-operator.pdf"`, Size: int64(len(content))})
+		if err := os.WriteFile(target+".part.json", meta, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := lib.Sync(ctx, eqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := statuses(results)["Construction/KX040-4/Operator's Manual.pdf"]; status != ManualResumed {
+			t.Fatalf("expected download to be resumed, got %s", status)
+		}
+		if bs, _ := os.ReadFile(target); !bytes.Equal(bs, content) {
+			t.Fatalf("expected complete manual after resuming, got %d bytes", len(bs))
+		}
+		for _, r := range take() {
+			if r.URL.Path == "/kx040-operator.pdf" && r.Header.Get("Range") != "bytes=300-" {
+				t.Fatalf("expected range request, got %q", r.Header.Get("Range"))
+			}
+		}
+		if _, err := os.Stat(target + ".part.json"); !os.IsNotExist(err) {
+			t.Fatalf("expected partial download state to be removed, got %v", err)
+		}
+	})
+
+	t.Run("failed manuals are reported", func(t *testing.T) {
+		broken := append([]Equipment{}, eqs...)
+		broken[2].ManualEntries = ManualEntries{{Name: "Operator: L2501", URL: srv.URL + "/gone"}}
+		broken = append(broken, Equipment{Model: "Z421", Category: "Mowers", ManualEntries: ManualEntries{
+			{Name: "Operator", URL: srv.URL + "/missing.pdf"},
+		}})
+		results, err := lib.Sync(ctx, broken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		failed := 0
+		for _, r := range results {
+			if r.Status == ManualFailed {
+				failed++
+				if r.Err == nil || !strings.Contains(r.Err.Error(), "404") {
+					t.Fatalf("expected not found error, got %v", r.Err)
+				}
+			}
+		}
+		if failed != 2 {
+			t.Fatalf("expected 2 failed manuals, got %d", failed)
+		}
+		index, err := lib.Index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(index.Manuals) != 2 {
+			t.Fatalf("expected manuals with a new URL to be dropped from the index, got %+v", index.Manuals)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package mykubota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Media is a file downloaded by a MediaCache
+type Media struct {
+	URL string `json:"url"`
+	// Path of the file on disk. Files are named by the SHA-256 of their content,
+	// so URLs serving identical content share a file
+	Path         string    `json:"path"`
+	SHA256       string    `json:"sha256"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+}
+
+// MediaCache downloads images referenced by the API, e.g. Model.ModelHeroURL, and keeps them on disk.
+// Content lives in <Dir>/objects, and the URL index in <Dir>/urls
+type MediaCache struct {
+	Dir string
+	// TTL after which cached media is revalidated. Zero keeps media forever
+	TTL time.Duration
+	// Client downloads media. Defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Fetch returns the cached media of rawURL, downloading it if it isn't cached or has expired
+func (mc *MediaCache) Fetch(ctx context.Context, rawURL string) (*Media, error) {
+	if rawURL == "" {
+		return nil, errors.New("missing media url")
+	}
+	cached, err := mc.lookup(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && (mc.TTL == 0 || time.Since(cached.FetchedAt) < mc.TTL) {
+		return cached, nil
+	}
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	client := mc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, []int{http.StatusOK, http.StatusNotModified}); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		if cached == nil {
+			return nil, fmt.Errorf("received unexpected not modified response for %s", rawURL)
+		}
+		cached.FetchedAt = time.Now()
+		return cached, mc.index(cached)
+	}
+
+	media, err := mc.store(rawURL, resp)
+	if err != nil {
+		return nil, err
+	}
+	return media, mc.index(media)
+}
+
+// indexPath returns the path of the index entry of rawURL
+func (mc *MediaCache) indexPath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(mc.Dir, "urls", hex.EncodeToString(sum[:])+".json")
+}
+
+// lookup returns the cached media of rawURL, or nil if it isn't cached
+func (mc *MediaCache) lookup(rawURL string) (*Media, error) {
+	bs, err := os.ReadFile(mc.indexPath(rawURL))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	media := Media{}
+	if err := json.Unmarshal(bs, &media); err != nil {
+		return nil, err
+	}
+	// the object might have been removed to free disk space
+	if _, err := os.Stat(media.Path); err != nil {
+		return nil, nil
+	}
+	return &media, nil
+}
+
+func (mc *MediaCache) index(media *Media) error {
+	bs, err := json.Marshal(media)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(mc.indexPath(media.URL), bs)
+}
+
+// store writes the response body to its content addressed location
+func (mc *MediaCache) store(rawURL string, resp *http.Response) (*Media, error) {
+	objects := filepath.Join(mc.Dir, "objects")
+	if err := os.MkdirAll(objects, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(objects, "download-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	target := filepath.Join(objects, sum[:2], sum+mediaExtension(rawURL))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, err
+	}
+	return &Media{
+		URL:          rawURL,
+		Path:         target,
+		SHA256:       sum,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Size:         size,
+		FetchedAt:    time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// mediaExtension keeps the file extension of the URL, so cached files open with the right application
+func mediaExtension(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".pdf":
+		return ext
+	}
+	return ""
+}
+
+// writeFileAtomic replaces path with data, so concurrent readers never observe a partial file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,76 @@
+package mykubota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMediaCache(t *testing.T) {
+	t.Parallel()
+
+	var downloads, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&downloads, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	cache := &MediaCache{Dir: t.TempDir()}
+	hero, err := cache.Fetch(ctx, srv.URL+"/models/KX040-4/hero.PNG?w=800")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := os.ReadFile(hero.Path); err != nil || string(bs) != "png" {
+		t.Fatalf("expected cached file, got %q: %v", bs, err)
+	}
+	if hero.ContentType != "image/png" || hero.Size != 3 || hero.SHA256 == "" {
+		t.Fatalf("unexpected media %+v", hero)
+	}
+
+	again, err := cache.Fetch(ctx, srv.URL+"/models/KX040-4/hero.PNG?w=800")
+	if err != nil {
+		t.Fatal(err)
+	}
+	icon, err := cache.Fetch(ctx, srv.URL+"/models/KX040-4/icon.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Path != hero.Path || icon.Path != hero.Path {
+		t.Fatalf("expected identical content to share a file, got %s, %s and %s", hero.Path, again.Path, icon.Path)
+	}
+	if downloads != 2 {
+		t.Fatalf("expected cached media to not be downloaded again, got %d downloads", downloads)
+	}
+
+	cache.TTL = time.Nanosecond
+	if _, err := cache.Fetch(ctx, srv.URL+"/models/KX040-4/icon.png"); err != nil {
+		t.Fatal(err)
+	}
+	if notModified != 1 || downloads != 2 {
+		t.Fatalf("expected expired media to be revalidated, got %d downloads and %d not modified", downloads, notModified)
+	}
+
+	_, err = cache.Fetch(ctx, srv.URL+"/missing.png")
+	statusErr := &StatusError{}
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
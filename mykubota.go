@@ -10,9 +10,11 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nicolai86/mykubota/pin"
 	"golang.org/x/oauth2"
 )
 
@@ -21,32 +23,48 @@ var (
 	AppEndpoint     = "https://app.mykubota.com"
 	AppClientID     = "1e74fe67-9753-4f65-b6e4-dd65a8132ea2"
 	AppClientSecret = "TCDx0qg5kFQhIdCxW0t1iFlESodtWfaR49vy4JdbYjc"
-	oauthConfig     = oauth2.Config{
-		ClientID:     AppClientID,
-		ClientSecret: AppClientSecret,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  "",
-			TokenURL: fmt.Sprintf("%s/oauth/token", AppEndpoint),
-		},
-		Scopes: []string{"read"},
-	}
 )
 
 // Client allows location specific access to public content from the MyKubota app
 type Client struct {
-	client *http.Client
-	locale string
-	debug  bool
+	client   *http.Client
+	endpoint string
+	locale   string
+	debug    bool
+	catalog  *CatalogCache
+	schema   *SchemaReport
+}
+
+// Option configures optional behaviour of a Client
+type Option func(*Client)
+
+// WithEndpoint overrides the MyKubota API endpoint, e.g. to talk to a local test server
+func WithEndpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithHTTPClient replaces the http.Client used to talk to the MyKubota API
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
 }
 
 // New creates a new MyKubota client for public content in the region specified by the locale
 // locale must be of format `{ISO 639-1}-{ISO 3166}`, ie en-US or en-CA
-func New(locale string) *Client {
-	return &Client{
-		client: &http.Client{},
-		locale: locale,
-		debug:  os.Getenv("DEBUG") != "",
+func New(locale string, opts ...Option) *Client {
+	c := &Client{
+		client:   &http.Client{},
+		endpoint: AppEndpoint,
+		locale:   locale,
+		debug:    os.Getenv("DEBUG") != "",
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Maintenance contains required maintenance including intervals for a specific model
@@ -62,18 +80,23 @@ type Maintenance struct {
 }
 
 func (c *Client) MaintenanceSchedule(model string) ([]Maintenance, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/maintenanceSchedule/%s", AppEndpoint, model), nil)
+	return c.maintenanceSchedule(context.Background(), model)
+}
+
+func (c *Client) maintenanceSchedule(ctx context.Context, model string) ([]Maintenance, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/maintenanceSchedule/%s", c.endpoint, model), nil)
 	if err != nil {
 		return nil, err
 	}
 	res := []Maintenance{}
-	if err := c.do(req, []int{http.StatusOK}, &res); err != nil {
+	if err := c.do(req.WithContext(ctx), []int{http.StatusOK}, jsonDecodeProcessor(&res)); err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
-func (s *Client) do(req *http.Request, acceptableHTTPCodes []int, res any) error {
+func (s *Client) do(req *http.Request, acceptableHTTPCodes []int, responseProcessor func(*http.Response) error) error {
+	req = req.WithContext(withSchemaReport(req.Context(), s.schema))
 	req.Header.Set("version", "2022_R03")
 	// locale is used by the backend to filter results for different countries. Ensure it's set to the country you're located in
 	req.Header.Set("Accept-Language", s.locale)
@@ -91,46 +114,117 @@ func (s *Client) do(req *http.Request, acceptableHTTPCodes []int, res any) error
 		log.Printf("< %s\n", string(bs))
 	}
 	defer resp.Body.Close()
-	isSuccessful := false
+	if err := checkStatus(resp, acceptableHTTPCodes); err != nil {
+		return err
+	}
+	return responseProcessor(resp)
+}
+
+// StatusError is returned when the MyKubota API responds with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	Expected   []int
+	// RetryAfter is taken from the Retry-After header, e.g. of 429 Too Many Requests responses
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("response code %d didn't match any expected http status codes %v", e.StatusCode, e.Expected)
+}
+
+func checkStatus(resp *http.Response, acceptableHTTPCodes []int) error {
 	for _, code := range acceptableHTTPCodes {
-		isSuccessful = isSuccessful || code == resp.StatusCode
+		if code == resp.StatusCode {
+			return nil
+		}
+	}
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Expected:   acceptableHTTPCodes,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter supports both delay seconds and HTTP dates
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
 	}
-	if !isSuccessful {
-		return fmt.Errorf("response code %d didn't match any expected http status codes %v", resp.StatusCode, acceptableHTTPCodes)
+	if t, err := http.ParseTime(v); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
 	}
-	return json.NewDecoder(resp.Body).Decode(res)
+	return 0
 }
 
 // Session allows location specific access to authenticated content
 type Session struct {
 	client *http.Client
-	Token  *oauth2.Token
-	locale string
-	debug  bool
+	// plain sends requests to other hosts, without leaking the token
+	plain    *http.Client
+	tokens   *reuseTokenSource
+	endpoint string
+	Token    *oauth2.Token
+	locale   string
+	debug    bool
+	schema   *SchemaReport
+}
+
+// oauthConfig returns the oauth configuration for the endpoint of the client
+func (c *Client) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     AppClientID,
+		ClientSecret: AppClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "",
+			TokenURL: fmt.Sprintf("%s/oauth/token", c.endpoint),
+		},
+		Scopes: []string{"read"},
+	}
+}
+
+// oauthContext makes the oauth2 package use the http.Client of the client
+func (c *Client) oauthContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, c.client)
+}
+
+// newSession creates a session talking to the endpoint of the client. The token is refreshed
+// when it expires, and written to store if set
+func (c *Client) newSession(ctx context.Context, t *oauth2.Token, store TokenStore) *Session {
+	tokens := &reuseTokenSource{config: c.oauthConfig(), ctx: c.oauthContext(ctx), token: t}
+	var ts oauth2.TokenSource = tokens
+	if store != nil {
+		ts = &storingTokenSource{base: tokens, store: store, last: t}
+	}
+	return &Session{
+		client: &http.Client{
+			Transport: &oauth2.Transport{Source: ts, Base: c.client.Transport},
+			Timeout:   c.client.Timeout,
+		},
+		plain:    c.client,
+		tokens:   tokens,
+		endpoint: c.endpoint,
+		Token:    t,
+		locale:   c.locale,
+		debug:    c.debug,
+		schema:   c.schema,
+	}
 }
 
 // SessionFromToken restores a session from an existing token
 func (c *Client) SessionFromToken(ctx context.Context, t *oauth2.Token) (*Session, error) {
-	return &Session{
-		client: oauthConfig.Client(ctx, t),
-		Token:  t,
-		locale: c.locale,
-		debug:  c.debug,
-	}, nil
+	return c.newSession(ctx, t, nil), nil
 }
 
 // Authenticate performs a password authentication with the MyKubota oauth API
 func (c *Client) Authenticate(ctx context.Context, username, password string) (*Session, error) {
-	token, err := oauthConfig.PasswordCredentialsToken(ctx, username, password)
+	token, err := c.oauthConfig().PasswordCredentialsToken(c.oauthContext(ctx), username, password)
 	if err != nil {
 		return nil, fmt.Errorf("failed oauth2: %v", err)
 	}
-	s := Session{
-		client: oauthConfig.Client(ctx, token),
-		Token:  token,
-		locale: c.locale,
-	}
-	return &s, nil
+	return c.newSession(ctx, token, nil), nil
 }
 
 // User contains basic informations about your MyKubota registration
@@ -143,7 +237,7 @@ type User struct {
 
 // User fetches the authenticated user for the current session
 func (s *Session) User(ctx context.Context) (*User, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/oauth/user", AppEndpoint), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/oauth/user", s.endpoint), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +287,9 @@ type EquipmentTelematics struct {
 	FaultCodes               []interface{}                 `json:"faultCodes"`
 	RestartInhibitStatus     EquipmentRestartInhibitStatus `json:"restartInhibitStatus"`
 	InsideGeofences          []interface{}                 `json:"insideGeofences"`
+
+	// Extra keeps fields the SDK doesn't map yet, so they survive re-encoding
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type ManualEntry struct {
@@ -225,21 +322,24 @@ type Equipment struct {
 	HasFaultCodes           bool    `json:"hasFaultCodes"`
 	HasMaintenanceSchedules bool    `json:"hasMaintenanceSchedules"`
 
-	// TODO - no use for these today, but they exist
-	// "modelHeroUrl"
-	// "modelFullUrl"
-	// "modelIconUrl"
-	// "warrantyUrl"
-	// "guideUrl"
+	ModelHeroURL string `json:"modelHeroUrl"`
+	ModelFullURL string `json:"modelFullUrl"`
+	ModelIconURL string `json:"modelIconUrl"`
+
+	WarrantyURL   string        `json:"warrantyUrl"`
+	GuideURL      string        `json:"guideUrl"`
 	ManualEntries ManualEntries `json:"manualEntries"`
 	VideoEntries  VideoEntries  `json:"videoEntries"`
 
 	Telematics EquipmentTelematics `json:"telematics"`
+
+	// Extra keeps fields the SDK doesn't map yet, so they survive re-encoding
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 func jsonDecodeProcessor(v any) func(*http.Response) error {
 	return func(resp *http.Response) error {
-		return json.NewDecoder(resp.Body).Decode(v)
+		return decodeJSON(resp, v)
 	}
 }
 
@@ -248,37 +348,59 @@ func noopProcessor(response *http.Response) error {
 }
 
 func (s *Session) do(req *http.Request, acceptableHTTPCodes []int, responseProcessor func(*http.Response) error) error {
+	req = req.WithContext(withSchemaReport(req.Context(), s.schema))
 	req.Header.Set("version", "2022_R03")
 	// locale is used by the backend to filter results for different countries. Ensure it's set to the country you're located in
 	req.Header.Set("Accept-Language", s.locale)
 
+	resp, err := s.send(req)
+	if err != nil {
+		return err
+	}
+	// the API rejected the token before it expired, e.g. because it was revoked.
+	// Force a refresh and try once more if the request can be replayed
+	if resp.StatusCode == http.StatusUnauthorized && s.tokens != nil && (req.Body == nil || req.GetBody != nil) {
+		resp.Body.Close()
+		s.tokens.expire()
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		if resp, err = s.send(req); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, acceptableHTTPCodes); err != nil {
+		return err
+	}
+	return responseProcessor(resp)
+}
+
+func (s *Session) send(req *http.Request) (*http.Response, error) {
 	if s.debug {
 		bs, _ := httputil.DumpRequest(req, true)
 		log.Printf("> %s\n", string(bs))
 	}
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if s.debug {
 		bs, _ := httputil.DumpResponse(resp, true)
 		log.Printf("< %s\n", string(bs))
 	}
-	defer resp.Body.Close()
-	isSuccessful := false
-	for _, code := range acceptableHTTPCodes {
-		isSuccessful = isSuccessful || code == resp.StatusCode
-	}
-	if !isSuccessful {
-		return fmt.Errorf("response code %d didn't match any expected http status codes %v", resp.StatusCode, acceptableHTTPCodes)
-	}
-	return responseProcessor(resp)
+	return resp, nil
 }
 
 // ListEquipment retrieves all equipment registered with the MyKubota app
 func (s *Session) ListEquipment(ctx context.Context) ([]Equipment, error) {
 	// TODO does the app support pagination? not that I can tell
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/equipment", AppEndpoint), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/equipment", s.endpoint), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -296,7 +418,7 @@ type Settings struct {
 
 // Settings loads user settings made in the MyKubota app
 func (s *Session) Settings(ctx context.Context) (*Settings, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/settings", AppEndpoint), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/settings", s.endpoint), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -312,7 +434,7 @@ func (s *Session) Settings(ctx context.Context) (*Settings, error) {
 
 // GetEquipment fetches a particular equipment by its ID
 func (s *Session) GetEquipment(ctx context.Context, id string) (*Equipment, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/equipment/%s", AppEndpoint, id), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/equipment/%s", s.endpoint, id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -325,7 +447,7 @@ func (s *Session) GetEquipment(ctx context.Context, id string) (*Equipment, erro
 
 // DeleteEquipment removes equipment associations for the current user
 func (s *Session) DeleteEquipment(ctx context.Context, id string) error {
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/user/equipment/%s", AppEndpoint, id), nil)
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/user/equipment/%s", s.endpoint, id), nil)
 	if err != nil {
 		return err
 	}
@@ -341,6 +463,10 @@ type UpdateEquipmentRequest struct {
 	EquipmentID string  `json:"id"`
 	EngineHours float64 `json:"engineHours"`
 	NickName    string  `json:"nickName"`
+
+	// Extra is sent along with the mapped fields. It's empty unless set explicitly, because it isn't known
+	// which fields of Equipment.Extra the API accepts; read-only fields shouldn't be sent back
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 func (s *Session) UpdateEquipment(ctx context.Context, req UpdateEquipmentRequest) (*Equipment, error) {
@@ -348,21 +474,27 @@ func (s *Session) UpdateEquipment(ctx context.Context, req UpdateEquipmentReques
 	if err := json.NewEncoder(&bs).Encode(req); err != nil {
 		return nil, err
 	}
-	httpReq, err := http.NewRequest("PUT", fmt.Sprintf("%s/api/user/equipment/update", AppEndpoint), &bs)
+	httpReq, err := http.NewRequest("PUT", fmt.Sprintf("%s/api/user/equipment/update", s.endpoint), &bs)
 	if err != nil {
 		return nil, err
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	res := []Equipment{}
-	err = s.do(httpReq, []int{http.StatusOK}, jsonDecodeProcessor(&res))
+	err = s.do(httpReq.WithContext(ctx), []int{http.StatusOK}, jsonDecodeProcessor(&res))
 	if err != nil {
 		return nil, err
 	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("update of equipment %s returned no equipment", req.EquipmentID)
+	}
 	return &res[0], nil
 }
 
-// AddEquipment adds equipment associations for the current user
+// AddEquipment adds equipment associations for the current user.
+// PinOrSerial is sent as normalized PIN if it has the shape of one, see pin.Identify, and as serial number otherwise.
+// Identifiers which don't look like either are sent unchanged, so the API decides whether it knows them.
+// Check digits aren't verified, because Kubota PINs don't follow the ISO 3779 scheme
 func (s *Session) AddEquipment(ctx context.Context, request AddEquipmentRequest) error {
 	type addMachineRequest struct {
 		Model       string `json:"model"`
@@ -371,18 +503,26 @@ func (s *Session) AddEquipment(ctx context.Context, request AddEquipmentRequest)
 		EngineHours int    `json:"engineHours"`
 		Type        string `json:"type"`
 	}
+	if pin.Normalize(request.PinOrSerial) == "" {
+		return pin.ErrEmpty
+	}
+	pinOrSerial, identifier := request.PinOrSerial, "Serial"
+	if pin.Identify(request.PinOrSerial) == pin.KindPIN {
+		pinOrSerial, identifier = pin.Normalize(request.PinOrSerial), "Pin"
+	}
 	bs := bytes.Buffer{}
 	json.NewEncoder(&bs).Encode(addMachineRequest{
 		Model:       request.Model.Model,
-		PinOrSerial: request.PinOrSerial,
-		Identifier:  "Serial",
+		PinOrSerial: pinOrSerial,
+		Identifier:  identifier,
 		Type:        "machine",
 	})
 
-	req, err := http.NewRequest("POST", "/api/user/equipment/addFromScan", bytes.NewReader(bs.Bytes()))
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/user/equipment/addFromScan", s.endpoint), bytes.NewReader(bs.Bytes()))
 	if err != nil {
 		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
 	return s.do(req.WithContext(ctx), []int{http.StatusOK}, noopProcessor)
 }
 
@@ -393,72 +533,47 @@ type CategoryNode struct {
 
 	SubCategories []*CategoryNode
 	Models        []Model
+
+	parent *CategoryNode
 }
 
 // GetModelTree returns a category tree containing all machines/ attachments currently offered by Kubota
+// Roots, subcategories and models are sorted, so repeated calls yield the same tree.
+// Categories referencing a missing parent, or a parent which would form a cycle, become roots,
+// see CategoryNode.Orphaned.
 func (c *Client) GetModelTree(ctx context.Context) ([]*CategoryNode, error) {
 	cs, ms, err := c.loadCategoriesAndModels(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	categoryModels := map[int][]Model{}
-	for _, m := range ms {
-		vs, ok := categoryModels[m.CategoryID]
-		if !ok {
-			vs = []Model{}
-		}
-		categoryModels[m.CategoryID] = append(vs, m)
-	}
-
-	roots := []*CategoryNode{}
-	categories := map[int]*CategoryNode{}
-	for _, c := range cs {
-		node := &CategoryNode{
-			ID:            c.ID,
-			Name:          c.Name,
-			SubCategories: []*CategoryNode{},
-			Models:        categoryModels[c.ID],
-			ParentID:      c.ParentID,
-		}
-		if c.ParentID == nil {
-			roots = append(roots, node)
-		}
-		categories[c.ID] = node
-	}
-	for _, node := range categories {
-		if node.ParentID == nil {
-			continue
-		}
-		parent := categories[*node.ParentID]
-		vs := parent.SubCategories
-		parent.SubCategories = append(vs, node)
-	}
-
-	return roots, nil
+	return buildModelTree(cs, ms), nil
 }
 
 type Category struct {
 	ID       int    `json:"id"`
 	Name     string `json:"name"`
 	ParentID *int   `json:"parentId"`
-	// heroUrl, fullUrl, iconUrl
+	HeroURL  string `json:"heroUrl"`
+	FullURL  string `json:"fullUrl"`
+	IconURL  string `json:"iconUrl"`
+
+	// Extra keeps fields the SDK doesn't map yet, so they survive re-encoding
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 func (c *Client) loadCategoriesAndModels(ctx context.Context) ([]Category, []Model, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/models", AppEndpoint), nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	type modelsResponse struct {
-		Categories []Category `json:"categories"`
-		Models     []Model    `json:"models"`
+	if c.catalog != nil {
+		snapshot, err := c.catalog.load(ctx, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		return snapshot.Categories, snapshot.Models, nil
 	}
-	var res = modelsResponse{}
-	if err := c.do(req.WithContext(ctx), []int{http.StatusOK}, &res); err != nil {
+	snapshot, err := c.fetchCatalog(ctx, nil)
+	if err != nil {
 		return nil, nil, err
 	}
-	return res.Categories, res.Models, nil
+	return snapshot.Categories, snapshot.Models, nil
 }
 
 // Listcategories returns all product categories offered by Kubota
@@ -468,18 +583,28 @@ func (s *Client) ListCategories(ctx context.Context) ([]Category, error) {
 }
 
 type Model struct {
-	CategoryID            int      `json:"categoryId"`
-	Type                  string   `json:"type"`
-	CompatibleAttachments []string `json:"compatibleAttachments"`
-	// categoryFullUrl, categoryHeroUrl, categoryIconUrl, guideUrl string
+	CategoryID              int           `json:"categoryId"`
+	Type                    string        `json:"type"`
+	CompatibleAttachments   []string      `json:"compatibleAttachments"`
+	CategoryFullURL         string        `json:"categoryFullUrl"`
+	CategoryHeroURL         string        `json:"categoryHeroUrl"`
+	CategoryIconURL         string        `json:"categoryIconUrl"`
+	GuideURL                string        `json:"guideUrl"`
 	HasFaultCodes           bool          `json:"hasFaultCodes"`
 	HasMaintenanceSchedules bool          `json:"hasMaintenanceSchedules"`
 	ManualEntries           []ManualEntry `json:"manualEntries"`
 	VideoEntries            []VideoEntry  `json:"videoEntries"`
 	Model                   string        `json:"model"`
-	// modelFullUrl, modelHeroUrl, modelIconUrl string
-	// subcategoryFullUrl, subcategoryHeroUrl, subcategoryIconUrl string
-	// warrantyUrl string
+	ModelFullURL            string        `json:"modelFullUrl"`
+	ModelHeroURL            string        `json:"modelHeroUrl"`
+	ModelIconURL            string        `json:"modelIconUrl"`
+	SubcategoryFullURL      string        `json:"subcategoryFullUrl"`
+	SubcategoryHeroURL      string        `json:"subcategoryHeroUrl"`
+	SubcategoryIconURL      string        `json:"subcategoryIconUrl"`
+	WarrantyURL             string        `json:"warrantyUrl"`
+
+	// Extra keeps fields the SDK doesn't map yet, so they survive re-encoding
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // ListModels returns all machines/ attachments offered by Kubota
@@ -493,9 +618,22 @@ type SearchMachineRequest struct {
 	Serial       string
 }
 
-// SearchMachine performs a location aware search in Kubotas registry for a matching model/ serial combination
+// SearchMachine performs a location aware search in Kubotas registry for a matching model/ serial combination.
+// Only the first match is returned, use SearchMachines to get all candidates
 func (c *Client) SearchMachine(ctx context.Context, request SearchMachineRequest) (*Model, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/models", AppEndpoint), nil)
+	models, err := c.searchRegistry(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(models) < 1 {
+		return nil, fmt.Errorf("didn't find a matching model")
+	}
+	return &models[0], nil
+}
+
+// searchRegistry returns all models Kubotas registry matches to request
+func (c *Client) searchRegistry(ctx context.Context, request SearchMachineRequest) ([]Model, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/models", c.endpoint), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -508,13 +646,10 @@ func (c *Client) SearchMachine(ctx context.Context, request SearchMachineRequest
 		Models []Model `json:"models"`
 	}
 	var res = modelsResponse{}
-	if err := c.do(req.WithContext(ctx), []int{http.StatusOK}, &res); err != nil {
+	if err := c.do(req.WithContext(ctx), []int{http.StatusOK}, jsonDecodeProcessor(&res)); err != nil {
 		return nil, err
 	}
-	if len(res.Models) < 1 {
-		return nil, fmt.Errorf("didn't find a matching model")
-	}
-	return &res.Models[0], nil
+	return res.Models, nil
 }
 
 type MaintenanceHistory struct {
@@ -526,10 +661,13 @@ type MaintenanceHistory struct {
 	UpdatedDate          time.Time `json:"updatedDate"`
 	// map of MaintenanceSchedule id to performed <Y/N>
 	MaintenanceCheckList map[string]bool `json:"maintenanceCheckList"`
+
+	// Extra keeps fields the SDK doesn't map yet, so they survive re-encoding
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 func (s *Session) MaintenanceHistory(equipmentID string) ([]MaintenanceHistory, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/equipment/%s/maintenanceHistory", AppEndpoint, equipmentID), nil)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/user/equipment/%s/maintenanceHistory", s.endpoint, equipmentID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -549,9 +687,10 @@ func (s *Session) RecordMaintenance(equipmentID string, entry MaintenanceHistory
 	if err := json.NewEncoder(&payload).Encode(entry); err != nil {
 		return err
 	}
-	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/api/user/equipment/%s/maintenanceHistory", AppEndpoint, equipmentID), &payload)
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/api/user/equipment/%s/maintenanceHistory", s.endpoint, equipmentID), &payload)
 	if err != nil {
 		return err
 	}
-	return s.do(req, []int{http.StatusOK}, nil)
+	req.Header.Set("Content-Type", "application/json")
+	return s.do(req, []int{http.StatusOK}, noopProcessor)
 }
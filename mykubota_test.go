@@ -2,20 +2,37 @@ package mykubota
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/nicolai86/mykubota/cassette"
 )
 
 var (
 	username = os.Getenv("MYKUBOTA_USERNAME")
 	password = os.Getenv("MYKUBOTA_PASSWORD")
 	shared   *Session
+
+	// cassettePath records the traffic of all tests if MYKUBOTA_RECORD is set, and replays it if it exists
+	cassettePath = filepath.Join("testdata", "cassettes", "integration.json")
+	// recordEndpoint sends recorded requests to another server, e.g. mykubota-fake, keeping the recorded URLs
+	recordEndpoint = os.Getenv("MYKUBOTA_RECORD_ENDPOINT")
+	// testOptions route clients through the cassette, see TestMain
+	testOptions []Option
+	replaying   bool
+	recording   bool
 )
 
 func skipIntegrationWithoutConfiguration(t *testing.T) {
+	if replaying {
+		return
+	}
 	if username == "" {
 		t.Skip("missing MYKUBOTA_USERNAME variable")
 	}
@@ -28,22 +45,69 @@ func hasConfiguration() bool {
 	return username != "" && password != ""
 }
 
+// skipWithoutCassette skips tests of public endpoints unless they are replayed, recorded or configured to run live
+func skipWithoutCassette(t *testing.T) {
+	if !replaying && !recording && !hasConfiguration() {
+		t.Skipf("missing cassette %s, set MYKUBOTA_RECORD to record it", cassettePath)
+	}
+}
+
+// redirectTransport sends requests to endpoint instead of their original host
+type redirectTransport struct {
+	endpoint *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host, req.Host = t.endpoint.Scheme, t.endpoint.Host, ""
+	return http.DefaultTransport.RoundTrip(req)
+}
+
 func TestMain(m *testing.M) {
-	if hasConfiguration() {
-		client := New("en-CA")
+	mode := cassette.Replay
+	if os.Getenv("MYKUBOTA_RECORD") != "" {
+		mode = cassette.Record
+	}
+	rec, err := cassette.New(cassettePath, mode)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		rec = nil
+	case err != nil:
+		log.Fatal(err)
+	default:
+		testOptions = []Option{WithHTTPClient(&http.Client{Transport: rec})}
+		replaying = mode == cassette.Replay
+		recording = mode == cassette.Record
+	}
+	if recording && recordEndpoint != "" {
+		endpoint, err := url.Parse(recordEndpoint)
+		if err != nil {
+			log.Fatalf("invalid MYKUBOTA_RECORD_ENDPOINT: %v", err)
+		}
+		rec.Transport = redirectTransport{endpoint: endpoint}
+	}
+
+	if hasConfiguration() || replaying {
+		client := New("en-CA", testOptions...)
 		session, err := client.Authenticate(context.Background(), username, password)
 		if err != nil {
 			log.Fatalf("expected login to succeed, but didn't: %v", err)
 		}
 		shared = session
 	}
-	os.Exit(m.Run())
+	code := m.Run()
+	if rec != nil {
+		if err := rec.Save(); err != nil {
+			log.Fatalf("unable to save cassette: %v", err)
+		}
+	}
+	os.Exit(code)
 }
 
 func TestNew(t *testing.T) {
 	skipIntegrationWithoutConfiguration(t)
 
-	client := New("en-CA")
+	client := New("en-CA", testOptions...)
 	session, err := client.Authenticate(context.Background(), username, password)
 	if err != nil {
 		t.Fatalf("expected login to succeed, but didn't: %v", err)
@@ -145,9 +209,10 @@ func TestSession_AddEquipment(t *testing.T) {
 }
 
 func TestClient_Categories(t *testing.T) {
+	skipWithoutCassette(t)
 	t.Parallel()
 
-	categories, err := New("en-CA").ListCategories(context.Background())
+	categories, err := New("en-CA", testOptions...).ListCategories(context.Background())
 	if err != nil {
 		t.Fatalf("expected api settings to succeed, but didn't: %v", err)
 	}
@@ -157,7 +222,9 @@ func TestClient_Categories(t *testing.T) {
 }
 
 func TestClient_MaintenanceSchedule(t *testing.T) {
-	client := New("en-CA")
+	skipWithoutCassette(t)
+
+	client := New("en-CA", testOptions...)
 	knownModels := []string{"KX040-4", "SVL97-2"}
 	for _, model := range knownModels {
 		schedule, err := client.MaintenanceSchedule(model)
@@ -171,9 +238,10 @@ func TestClient_MaintenanceSchedule(t *testing.T) {
 }
 
 func TestClient_Models(t *testing.T) {
+	skipWithoutCassette(t)
 	t.Parallel()
 
-	models, err := New("en-CA").ListModels(context.Background())
+	models, err := New("en-CA", testOptions...).ListModels(context.Background())
 	if err != nil {
 		t.Fatalf("expected api settings to succeed, but didn't: %v", err)
 	}
@@ -183,9 +251,10 @@ func TestClient_Models(t *testing.T) {
 }
 
 func TestClient_SearchMachine(t *testing.T) {
+	skipWithoutCassette(t)
 	t.Parallel()
 
-	model, err := New("en-CA").SearchMachine(context.Background(), SearchMachineRequest{
+	model, err := New("en-CA", testOptions...).SearchMachine(context.Background(), SearchMachineRequest{
 		PartialModel: "kx0",
 		Serial:       "1",
 	})
@@ -200,9 +269,10 @@ func TestClient_SearchMachine(t *testing.T) {
 }
 
 func TestClient_GetModelTree(t *testing.T) {
+	skipWithoutCassette(t)
 	t.Parallel()
 
-	roots, err := New("en-CA").GetModelTree(context.Background())
+	roots, err := New("en-CA", testOptions...).GetModelTree(context.Background())
 	if err != nil {
 		t.Fatalf("expected api settings to succeed, but didn't: %v", err)
 	}
@@ -0,0 +1,154 @@
+package mykubotatest
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FaultKind selects how a request affected by a Fault fails
+type FaultKind int
+
+const (
+	// FaultNone serves the request normally, after the fault's latency
+	FaultNone FaultKind = iota
+	// FaultStatus responds with the fault's status code, 500 by default
+	FaultStatus
+	// FaultMalformedJSON responds with 200 OK and a body which isn't JSON
+	FaultMalformedJSON
+	// FaultTruncatedBody serves the request normally but closes the connection halfway through the body
+	FaultTruncatedBody
+	// FaultExpiredToken revokes the access token of the request and responds with 401 Unauthorized,
+	// so clients have to refresh their token
+	FaultExpiredToken
+	// FaultConnectionReset resets the connection without responding
+	FaultConnectionReset
+)
+
+// Fault describes a failure injected into matching requests
+type Fault struct {
+	// Method of affected requests, any method if empty
+	Method string
+	// Path of affected requests. A trailing * matches any path with that prefix, an empty path all requests
+	Path string
+	// Times limits the number of affected requests. Zero affects all matching requests
+	Times int
+
+	// Latency delays the response
+	Latency time.Duration
+	Kind    FaultKind
+	// Status is the status code of FaultStatus, defaulting to 500
+	Status int
+	// RetryAfter is sent as Retry-After header with FaultStatus
+	RetryAfter time.Duration
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	if f.Method != "" && f.Method != r.Method {
+		return false
+	}
+	if prefix := strings.TrimSuffix(f.Path, "*"); prefix != f.Path {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+	return f.Path == "" || f.Path == r.URL.Path
+}
+
+// InjectFault makes matching requests fail. Faults are consulted in the order they were
+// injected and the first matching one applies, so sequences like "fail twice, then succeed"
+// are scripted by injecting faults with Times set
+func (h *Handler) InjectFault(f Fault) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.faults = append(h.faults, &f)
+}
+
+// ClearFaults removes all injected faults
+func (h *Handler) ClearFaults() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.faults = nil
+}
+
+// takeFault returns the fault applying to r, if any, and counts it against its limit
+func (h *Handler) takeFault(r *http.Request) *Fault {
+	for i, f := range h.faults {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				h.faults = append(h.faults[:i:i], h.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (h *Handler) serveFault(w http.ResponseWriter, r *http.Request, body []byte, f Fault) {
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch f.Kind {
+	case FaultStatus:
+		status := f.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		if f.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter.Round(time.Second)/time.Second)))
+		}
+		writeError(w, status, http.StatusText(status))
+
+	case FaultMalformedJSON:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+
+	case FaultTruncatedBody:
+		rec := httptest.NewRecorder()
+		h.serve(rec, r, body)
+		for k, vs := range rec.Header() {
+			w.Header()[k] = vs
+		}
+		// declaring the full length makes the server close the connection after the short body
+		w.Header().Set("Content-Length", strconv.Itoa(rec.Body.Len()))
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes()[:rec.Body.Len()/2])
+
+	case FaultExpiredToken:
+		h.mu.Lock()
+		if auth := r.Header.Get("Authorization"); len(auth) > len("bearer ") {
+			delete(h.accessTokens, auth[len("bearer "):])
+		}
+		h.mu.Unlock()
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="The access token expired"`)
+		writeError(w, http.StatusUnauthorized, "token expired")
+
+	case FaultConnectionReset:
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			panic("mykubotatest: connection reset requires a hijackable connection")
+		}
+		conn, _, err := hijacker.Hijack()
+		if err != nil {
+			panic(err)
+		}
+		// a zero linger makes close send a RST instead of a FIN
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.SetLinger(0)
+		}
+		conn.Close()
+
+	default:
+		h.serve(w, r, body)
+	}
+}
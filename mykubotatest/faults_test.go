@@ -0,0 +1,103 @@
+package mykubotatest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/mykubotatest"
+)
+
+func TestHandler_InjectFault(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		fault  mykubotatest.Fault
+		status int
+		retry  time.Duration
+	}{
+		{name: "rate limited", fault: mykubotatest.Fault{Kind: mykubotatest.FaultStatus, Status: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}, status: http.StatusTooManyRequests, retry: 3 * time.Second},
+		{name: "server error", fault: mykubotatest.Fault{Kind: mykubotatest.FaultStatus}, status: http.StatusInternalServerError},
+		{name: "malformed json", fault: mykubotatest.Fault{Kind: mykubotatest.FaultMalformedJSON}},
+		{name: "truncated body", fault: mykubotatest.Fault{Kind: mykubotatest.FaultTruncatedBody}},
+		{name: "connection reset", fault: mykubotatest.Fault{Kind: mykubotatest.FaultConnectionReset}},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+			session := srv.Session(t, mykubotatest.DefaultUsername)
+			tc.fault.Path = "/api/user/equipment"
+			srv.InjectFault(tc.fault)
+
+			_, err := session.ListEquipment(context.Background())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			statusErr := &mykubota.StatusError{}
+			if errors.As(err, &statusErr) != (tc.status != 0) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tc.status != 0 && (statusErr.StatusCode != tc.status || statusErr.RetryAfter != tc.retry) {
+				t.Fatalf("unexpected status error %+v", statusErr)
+			}
+		})
+	}
+}
+
+func TestHandler_InjectFault_Times(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	session := srv.Session(t, mykubotatest.DefaultUsername)
+	srv.InjectFault(mykubotatest.Fault{Method: http.MethodGet, Path: "/api/user/*", Kind: mykubotatest.FaultStatus, Status: http.StatusServiceUnavailable, Times: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := session.Settings(context.Background()); err == nil {
+			t.Fatalf("expected request %d to fail", i)
+		}
+	}
+	if _, err := session.Settings(context.Background()); err != nil {
+		t.Fatalf("expected fault to be exhausted, got %v", err)
+	}
+}
+
+func TestHandler_InjectFault_Latency(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	session := srv.Session(t, mykubotatest.DefaultUsername)
+	srv.InjectFault(mykubotatest.Fault{Path: "/api/user/equipment", Latency: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := session.ListEquipment(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestHandler_InjectFault_ExpiredToken(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	session := srv.Session(t, mykubotatest.DefaultUsername)
+	srv.InjectFault(mykubotatest.Fault{Path: "/api/user/equipment/update", Kind: mykubotatest.FaultExpiredToken, Times: 1})
+
+	// the rejected request is replayed including its body after refreshing the token
+	req := mykubota.UpdateEquipmentRequest{EquipmentID: "8f7c1a52-54b5-4a8c-9a3e-2c6b3a1f0e01", NickName: "Big Digger"}
+	if _, err := session.UpdateEquipment(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertRequestCount(t, http.MethodPost, "/oauth/token", 2)
+	srv.AssertRequestCount(t, http.MethodPut, "/api/user/equipment/update", 2)
+	srv.Seed(func(f *mykubotatest.Fixtures) {
+		if nickname := f.Accounts[0].Equipment[0].Nickname; nickname != "Big Digger" {
+			t.Fatalf("expected equipment to be updated, got %q", nickname)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package mykubotatest
+
+import (
+	"time"
+
+	"github.com/nicolai86/mykubota"
+)
+
+// Default credentials of the account in DefaultFixtures
+const (
+	DefaultUsername = "demo@example.com"
+	DefaultPassword = "demo"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// DefaultFixtures returns a small catalog and an account owning an excavator with
+// telematics and a tractor. Every call returns a fresh copy which can be modified freely
+func DefaultFixtures() Fixtures {
+	return Fixtures{
+		Accounts: []*Account{
+			{
+				Username: DefaultUsername,
+				Password: DefaultPassword,
+				User:     mykubota.User{Email: DefaultUsername, EmailVerified: true},
+				Settings: mykubota.Settings{MeasurementUnit: "metric"},
+				Equipment: []mykubota.Equipment{
+					{
+						ID:                      "8f7c1a52-54b5-4a8c-9a3e-2c6b3a1f0e01",
+						Model:                   "KX040-4",
+						CategoryID:              2,
+						Category:                "Construction",
+						SubCategory:             "Compact Excavators",
+						IdentifierType:          "Pin",
+						Type:                    "machine",
+						PinOrSerial:             "KBCDZ26CEN3K12345",
+						Pin:                     "KBCDZ26CEN3K12345",
+						Nickname:                "Digger",
+						UserEnteredEngineHours:  412.5,
+						HasTelematics:           true,
+						HasMaintenanceSchedules: true,
+						Telematics: mykubota.EquipmentTelematics{
+							LocationTime:             time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC),
+							CumulativeOperatingHours: 418.2,
+							Location:                 mykubota.EquipmentLocation{Latitude: 49.2827, Longitude: -123.1207},
+							FuelRemainingPercent:     64,
+							DEFRemainingPercent:      80,
+							MotionState:              "parked",
+						},
+					},
+					{
+						ID:                      "8f7c1a52-54b5-4a8c-9a3e-2c6b3a1f0e02",
+						Model:                   "L2501",
+						CategoryID:              3,
+						Category:                "Tractors",
+						IdentifierType:          "Serial",
+						Type:                    "machine",
+						PinOrSerial:             "54321",
+						Serial:                  "54321",
+						UserEnteredEngineHours:  96,
+						HasMaintenanceSchedules: true,
+					},
+				},
+				MaintenanceHistory: map[string][]mykubota.MaintenanceHistory{
+					"8f7c1a52-54b5-4a8c-9a3e-2c6b3a1f0e01": {
+						{
+							ID:                   "2b1d7d0e-6f1a-4c3e-8d7b-1e0c9f5a4b01",
+							IntervalType:         "Every X Hours",
+							IntervalValue:        250,
+							CompletedEngineHours: 251,
+							Notes:                "Oil change",
+							UpdatedDate:          time.Date(2022, 5, 2, 9, 30, 0, 0, time.UTC),
+							MaintenanceCheckList: map[string]bool{"kx040-4-1": true},
+						},
+					},
+				},
+			},
+		},
+		Categories: []mykubota.Category{
+			{ID: 1, Name: "Construction"},
+			{ID: 2, Name: "Compact Excavators", ParentID: intPtr(1)},
+			{ID: 3, Name: "Tractors"},
+			{ID: 4, Name: "Compact Tractors", ParentID: intPtr(3)},
+		},
+		Models: []mykubota.Model{
+			{Model: "KX040-4", CategoryID: 2, Type: "machine", HasMaintenanceSchedules: true},
+			{Model: "KX057-4", CategoryID: 2, Type: "machine", HasMaintenanceSchedules: true},
+			{Model: "L2501", CategoryID: 4, Type: "machine", HasMaintenanceSchedules: true},
+		},
+		MaintenanceSchedules: map[string][]mykubota.Maintenance{
+			"KX040-4": {
+				{ID: "kx040-4-1", CheckPoint: "Engine oil", Measures: "Change", DisplayIntervalType: "Every X Hours", IntervalTyp: "Every X Hours", IntervalValue: 250, FirstCheckValue: 50, SortOrder: 1},
+				{ID: "kx040-4-2", CheckPoint: "Fuel filter", Measures: "Replace", DisplayIntervalType: "Every X Hours", IntervalTyp: "Every X Hours", IntervalValue: 500, SortOrder: 2},
+			},
+			"KX057-4": {
+				{ID: "kx057-4-1", CheckPoint: "Engine oil", Measures: "Change", DisplayIntervalType: "Every X Hours", IntervalTyp: "Every X Hours", IntervalValue: 250, FirstCheckValue: 50, SortOrder: 1},
+			},
+			"L2501": {
+				{ID: "l2501-1", CheckPoint: "Transmission fluid", Measures: "Change", DisplayIntervalType: "Every X Hours", IntervalTyp: "Every X Hours", IntervalValue: 400, SortOrder: 1},
+			},
+		},
+	}
+}
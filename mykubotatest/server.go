@@ -0,0 +1,540 @@
+// Package mykubotatest provides an in-memory MyKubota API for tests
+package mykubotatest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nicolai86/mykubota"
+)
+
+// Account is a MyKubota user and everything registered with it
+type Account struct {
+	Username  string               `json:"username"`
+	Password  string               `json:"password"`
+	User      mykubota.User        `json:"user"`
+	Settings  mykubota.Settings    `json:"settings"`
+	Equipment []mykubota.Equipment `json:"equipment"`
+	// MaintenanceHistory is keyed by equipment ID
+	MaintenanceHistory map[string][]mykubota.MaintenanceHistory `json:"maintenanceHistory,omitempty"`
+}
+
+// Fixtures is the data served by a Handler
+type Fixtures struct {
+	Accounts   []*Account          `json:"accounts"`
+	Categories []mykubota.Category `json:"categories"`
+	Models     []mykubota.Model    `json:"models"`
+	// MaintenanceSchedules is keyed by model name
+	MaintenanceSchedules map[string][]mykubota.Maintenance `json:"maintenanceSchedules"`
+}
+
+// account returns the account with the given username, or nil
+func (f *Fixtures) account(username string) *Account {
+	for _, a := range f.Accounts {
+		if a.Username == username {
+			return a
+		}
+	}
+	return nil
+}
+
+// Request is a request received by a Handler
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+	// Username of the account the request was authenticated as, if any
+	Username string
+}
+
+type accessToken struct {
+	account *Account
+	expiry  time.Time
+}
+
+// Handler implements the MyKubota API on top of fixtures.
+// Requests modifying equipment, settings or maintenance history change the fixtures.
+type Handler struct {
+	// TokenLifetime controls the expiry of issued access tokens. Defaults to an hour
+	TokenLifetime time.Duration
+
+	mu            sync.Mutex
+	fixtures      Fixtures
+	accessTokens  map[string]accessToken
+	refreshTokens map[string]*Account
+	requests      []Request
+	faults        []*Fault
+}
+
+// NewHandler returns a handler serving the fixtures
+func NewHandler(f Fixtures) *Handler {
+	if f.MaintenanceSchedules == nil {
+		f.MaintenanceSchedules = map[string][]mykubota.Maintenance{}
+	}
+	return &Handler{
+		fixtures:      f,
+		accessTokens:  map[string]accessToken{},
+		refreshTokens: map[string]*Account{},
+	}
+}
+
+// Seed runs fn with exclusive access to the fixtures, to add data or to inspect
+// changes made by requests
+func (h *Handler) Seed(fn func(f *Fixtures)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(&h.fixtures)
+}
+
+// Requests returns all requests received since the handler was created or last reset
+func (h *Handler) Requests() []Request {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Request{}, h.requests...)
+}
+
+// Reset forgets all received requests
+func (h *Handler) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests = nil
+}
+
+// AssertRequested fails the test unless a request matching method and path was received.
+// The last matching request is returned for further inspection
+func (h *Handler) AssertRequested(t testing.TB, method, path string) Request {
+	t.Helper()
+	requests := h.Requests()
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].Method == method && requests[i].Path == path {
+			return requests[i]
+		}
+	}
+	t.Fatalf("expected request %s %s, got %s", method, path, formatRequests(requests))
+	return Request{}
+}
+
+// AssertNotRequested fails the test if a request matching method and path was received
+func (h *Handler) AssertNotRequested(t testing.TB, method, path string) {
+	t.Helper()
+	for _, r := range h.Requests() {
+		if r.Method == method && r.Path == path {
+			t.Fatalf("expected no request %s %s, but got one", method, path)
+		}
+	}
+}
+
+// AssertRequestCount fails the test unless exactly n requests matching method and path were received
+func (h *Handler) AssertRequestCount(t testing.TB, method, path string, n int) {
+	t.Helper()
+	count := 0
+	for _, r := range h.Requests() {
+		if r.Method == method && r.Path == path {
+			count++
+		}
+	}
+	if count != n {
+		t.Fatalf("expected %d requests %s %s, got %d", n, method, path, count)
+	}
+}
+
+func formatRequests(requests []Request) string {
+	if len(requests) == 0 {
+		return "none"
+	}
+	lines := []string{}
+	for _, r := range requests {
+		lines = append(lines, r.Method+" "+r.Path)
+	}
+	return strings.Join(lines, ", ")
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	account := h.authenticate(r)
+	req := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	if account != nil {
+		req.Username = account.Username
+	}
+	h.requests = append(h.requests, req)
+	fault := h.takeFault(r)
+	h.mu.Unlock()
+
+	if fault != nil {
+		h.serveFault(w, r, body, *fault)
+		return
+	}
+	h.serve(w, r, body)
+}
+
+// serve routes the request to the API implementation
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, body []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	account := h.authenticate(r)
+	switch {
+	case r.URL.Path == "/oauth/token":
+		h.serveToken(w, r, body)
+	case r.URL.Path == "/api/models":
+		h.serveModels(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/maintenanceSchedule/"):
+		h.serveMaintenanceSchedule(w, r, strings.TrimPrefix(r.URL.Path, "/api/maintenanceSchedule/"))
+	case account == nil:
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+	default:
+		h.serveAccount(w, r, body, account)
+	}
+}
+
+// authenticate returns the account of a valid bearer token
+func (h *Handler) authenticate(r *http.Request) *Account {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		return nil
+	}
+	t, ok := h.accessTokens[auth[len("bearer "):]]
+	if !ok || time.Now().After(t.expiry) {
+		return nil
+	}
+	return t.account
+}
+
+func (h *Handler) serveToken(w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = form.Get("client_id"), form.Get("client_secret")
+	}
+	if clientID != mykubota.AppClientID || clientSecret != mykubota.AppClientSecret {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	var account *Account
+	switch form.Get("grant_type") {
+	case "password":
+		account = h.fixtures.account(form.Get("username"))
+		if account == nil || account.Password != form.Get("password") {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	case "refresh_token":
+		refreshToken := form.Get("refresh_token")
+		account = h.refreshTokens[refreshToken]
+		if account == nil {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		delete(h.refreshTokens, refreshToken)
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	lifetime := h.TokenLifetime
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	access, refresh := uuid.NewString(), uuid.NewString()
+	h.accessTokens[access] = accessToken{account: account, expiry: time.Now().Add(lifetime)}
+	h.refreshTokens[refresh] = account
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"token_type":    "bearer",
+		"refresh_token": refresh,
+		"expires_in":    int(lifetime.Seconds()),
+		"scope":         "read",
+	})
+}
+
+func (h *Handler) serveModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+	if query.Has("partialModel") || query.Has("serial") {
+		partial := strings.ToLower(query.Get("partialModel"))
+		models := []mykubota.Model{}
+		for _, m := range h.fixtures.Models {
+			if strings.HasPrefix(strings.ToLower(m.Model), partial) {
+				models = append(models, m)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"models": models})
+		return
+	}
+
+	bs, err := json.Marshal(map[string]any{
+		"categories": nonNil(h.fixtures.Categories),
+		"models":     nonNil(h.fixtures.Models),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(bs))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bs)
+}
+
+func (h *Handler) serveMaintenanceSchedule(w http.ResponseWriter, r *http.Request, model string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	schedule, ok := h.fixtures.MaintenanceSchedules[model]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown model")
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(schedule))
+}
+
+func (h *Handler) serveAccount(w http.ResponseWriter, r *http.Request, body []byte, account *Account) {
+	path := r.URL.Path
+	switch {
+	case path == "/oauth/user" && r.Method == http.MethodGet:
+		user := account.User
+		if user.Email == "" {
+			user.Email = account.Username
+		}
+		writeJSON(w, http.StatusOK, user)
+
+	case path == "/api/user/settings" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"settings": account.Settings})
+
+	case path == "/api/user/equipment" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, nonNil(account.Equipment))
+	case path == "/api/user/equipment/update" && r.Method == http.MethodPut:
+		h.updateEquipment(w, body, account)
+	case path == "/api/user/equipment/addFromScan" && r.Method == http.MethodPost:
+		h.addEquipment(w, body, account)
+
+	case strings.HasPrefix(path, "/api/user/equipment/"):
+		id := strings.TrimPrefix(path, "/api/user/equipment/")
+		history := strings.HasSuffix(id, "/maintenanceHistory")
+		id = strings.TrimSuffix(id, "/maintenanceHistory")
+		idx := equipmentIndex(account, id)
+		if idx < 0 || strings.Contains(id, "/") {
+			writeError(w, http.StatusNotFound, "unknown equipment")
+			return
+		}
+		switch {
+		case history && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, nonNil(account.MaintenanceHistory[id]))
+		case history && r.Method == http.MethodPut:
+			h.recordMaintenance(w, body, account, id)
+		case !history && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, account.Equipment[idx])
+		case !history && r.Method == http.MethodDelete:
+			account.Equipment = append(account.Equipment[:idx:idx], account.Equipment[idx+1:]...)
+			delete(account.MaintenanceHistory, id)
+			w.WriteHeader(http.StatusOK)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func equipmentIndex(account *Account, id string) int {
+	for i, eq := range account.Equipment {
+		if eq.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *Handler) updateEquipment(w http.ResponseWriter, body []byte, account *Account) {
+	req := mykubota.UpdateEquipmentRequest{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx := equipmentIndex(account, req.EquipmentID)
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, "unknown equipment")
+		return
+	}
+	eq := &account.Equipment[idx]
+	eq.UserEnteredEngineHours = req.EngineHours
+	eq.Nickname = req.NickName
+	writeJSON(w, http.StatusOK, []mykubota.Equipment{*eq})
+}
+
+func (h *Handler) addEquipment(w http.ResponseWriter, body []byte, account *Account) {
+	req := struct {
+		Model          string  `json:"model"`
+		PinOrSerial    string  `json:"pinOrSerial"`
+		IdentifierType string  `json:"identifierType"`
+		EngineHours    float64 `json:"engineHours"`
+		Type           string  `json:"type"`
+	}{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var model *mykubota.Model
+	for i := range h.fixtures.Models {
+		if h.fixtures.Models[i].Model == req.Model {
+			model = &h.fixtures.Models[i]
+		}
+	}
+	if model == nil || req.PinOrSerial == "" {
+		writeError(w, http.StatusBadRequest, "unknown model or missing pin/serial")
+		return
+	}
+
+	eq := mykubota.Equipment{
+		ID:                      uuid.NewString(),
+		Model:                   model.Model,
+		CategoryID:              model.CategoryID,
+		IdentifierType:          req.IdentifierType,
+		Type:                    req.Type,
+		PinOrSerial:             req.PinOrSerial,
+		UserEnteredEngineHours:  req.EngineHours,
+		HasFaultCodes:           model.HasFaultCodes,
+		HasMaintenanceSchedules: model.HasMaintenanceSchedules,
+		ManualEntries:           model.ManualEntries,
+		VideoEntries:            model.VideoEntries,
+	}
+	if strings.EqualFold(req.IdentifierType, "pin") {
+		eq.Pin = req.PinOrSerial
+	} else {
+		eq.Serial = req.PinOrSerial
+	}
+	for _, c := range h.fixtures.Categories {
+		if c.ID == model.CategoryID {
+			eq.Category = c.Name
+		}
+	}
+	account.Equipment = append(account.Equipment, eq)
+	writeJSON(w, http.StatusOK, eq)
+}
+
+func (h *Handler) recordMaintenance(w http.ResponseWriter, body []byte, account *Account, equipmentID string) {
+	entry := mykubota.MaintenanceHistory{}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if account.MaintenanceHistory == nil {
+		account.MaintenanceHistory = map[string][]mykubota.MaintenanceHistory{}
+	}
+	entry.UpdatedDate = time.Now().UTC()
+	history := account.MaintenanceHistory[equipmentID]
+	for i := range history {
+		if history[i].ID == entry.ID {
+			history[i] = entry
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	account.MaintenanceHistory[equipmentID] = append(history, entry)
+	w.WriteHeader(http.StatusOK)
+}
+
+// nonNil makes sure empty lists are encoded as [] like the MyKubota API does
+func nonNil[T any](vs []T) []T {
+	if vs == nil {
+		return []T{}
+	}
+	return vs
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	bs := bytes.Buffer{}
+	if err := json.NewEncoder(&bs).Encode(v); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bs.Bytes())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+// Server is a Handler served by an httptest.Server
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a server for the fixtures which is closed when the test finishes
+func NewServer(t testing.TB, f Fixtures) *Server {
+	h := NewHandler(f)
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &Server{Server: srv, Handler: h}
+}
+
+// NewClient returns a client talking to the server
+func (s *Server) NewClient(locale string, opts ...mykubota.Option) *mykubota.Client {
+	return mykubota.New(locale, append([]mykubota.Option{mykubota.WithEndpoint(s.URL)}, opts...)...)
+}
+
+// Session authenticates as the account with the given username, failing the test on errors
+func (s *Server) Session(t testing.TB, username string) *mykubota.Session {
+	t.Helper()
+	password := ""
+	s.Seed(func(f *Fixtures) {
+		if a := f.account(username); a != nil {
+			password = a.Password
+		}
+	})
+	session, err := s.NewClient("en-CA").Authenticate(context.Background(), username, password)
+	if err != nil {
+		t.Fatalf("unable to authenticate %s: %v", username, err)
+	}
+	return session
+}
@@ -0,0 +1,99 @@
+package mykubotatest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/mykubotatest"
+)
+
+func TestServer_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	client := srv.NewClient("en-CA")
+	if _, err := client.Authenticate(context.Background(), mykubotatest.DefaultUsername, "wrong"); err == nil {
+		t.Fatal("expected authentication with wrong password to fail")
+	}
+
+	session, err := client.Authenticate(context.Background(), mykubotatest.DefaultUsername, mykubotatest.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := session.User(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != mykubotatest.DefaultUsername {
+		t.Fatalf("unexpected user %+v", user)
+	}
+	req := srv.AssertRequested(t, http.MethodGet, "/oauth/user")
+	if req.Username != mykubotatest.DefaultUsername {
+		t.Fatalf("expected request to be authenticated, got %q", req.Username)
+	}
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	resp, err := http.Get(srv.URL + "/api/user/equipment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_RefreshToken(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	// tokens expiring within seconds are refreshed by the oauth2 package before every request
+	srv.TokenLifetime = time.Second
+	session := srv.Session(t, mykubotatest.DefaultUsername)
+	for i := 0; i < 2; i++ {
+		if _, err := session.ListEquipment(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.AssertRequestCount(t, http.MethodPost, "/oauth/token", 3)
+}
+
+func TestServer_Seed(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.Fixtures{})
+	srv.Seed(func(f *mykubotatest.Fixtures) {
+		f.Accounts = append(f.Accounts, &mykubotatest.Account{Username: "a@example.com", Password: "a"})
+		f.Models = append(f.Models, mykubota.Model{Model: "SVL97-2", CategoryID: 1})
+	})
+	session := srv.Session(t, "a@example.com")
+	eqs, err := session.ListEquipment(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eqs) != 0 {
+		t.Fatalf("expected no equipment, got %v", eqs)
+	}
+
+	if err := session.AddEquipment(context.Background(), mykubota.AddEquipmentRequest{
+		Model:       &mykubota.Model{Model: "SVL97-2"},
+		PinOrSerial: "12345",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv.Seed(func(f *mykubotatest.Fixtures) {
+		eqs = f.Accounts[0].Equipment
+	})
+	if len(eqs) != 1 || eqs[0].Model != "SVL97-2" || eqs[0].Serial != "12345" {
+		t.Fatalf("unexpected equipment %+v", eqs)
+	}
+	srv.AssertRequestCount(t, http.MethodPost, "/api/user/equipment/addFromScan", 1)
+	srv.AssertNotRequested(t, http.MethodDelete, "/api/user/equipment/"+eqs[0].ID)
+}
@@ -0,0 +1,400 @@
+// Package output renders SDK types as table, JSON, NDJSON, CSV, YAML or Go templates,
+// so every command and exporter shares the same formats and column selection.
+//
+// Columns are addressed by their JSON names. Nested fields are joined by dots,
+// e.g. telematics.fuelRemainingPercent
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported formats
+const (
+	Table    = "table"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	CSV      = "csv"
+	YAML     = "yaml"
+	Template = "template"
+)
+
+// Formats lists all supported formats
+var Formats = []string{Table, JSON, NDJSON, CSV, YAML, Template}
+
+// Options configure how values are rendered
+type Options struct {
+	// Format is one of Formats. Defaults to Table, or Template if Template is set
+	Format string
+	// Columns limits and orders the rendered fields. All fields are rendered if empty
+	Columns []string
+	// Template is a Go template executed once per record. Sprig functions are available
+	Template string
+}
+
+// ParseColumns splits a comma separated column list
+func ParseColumns(s string) []string {
+	columns := []string{}
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// Write renders v, which is either a single value or a slice of records
+func Write(w io.Writer, v any, opts Options) error {
+	format := opts.Format
+	if format == "" {
+		format = Table
+		if opts.Template != "" {
+			format = Template
+		}
+	}
+
+	if format == Template {
+		return writeTemplate(w, v, opts.Template)
+	}
+
+	records, isList, err := toRecords(v)
+	if err != nil {
+		return err
+	}
+	if len(opts.Columns) > 0 {
+		for i, r := range records {
+			records[i] = project(r, opts.Columns)
+		}
+	}
+
+	switch format {
+	case Table:
+		return writeTable(w, records, opts.Columns)
+	case CSV:
+		return writeCSV(w, records, opts.Columns)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if isList {
+			return enc.Encode(records)
+		}
+		return enc.Encode(records[0])
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		if isList {
+			return enc.Encode(toYAML(records))
+		}
+		return enc.Encode(toYAML(records[0]))
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// toRecords converts v into its JSON representation, keeping the field order.
+// Nil slices are treated like empty slices, so they render as lists without records
+func toRecords(v any) ([]any, bool, error) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		return []any{}, true, nil
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, false, err
+	}
+	decoded, err := decodeOrdered(json.NewDecoder(bytes.NewReader(bs)))
+	if err != nil {
+		return nil, false, err
+	}
+	if list, ok := decoded.([]any); ok {
+		return list, true, nil
+	}
+	return []any{decoded}, false, nil
+}
+
+func writeTemplate(w io.Writer, v any, text string) error {
+	tmpl, err := template.New("format").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	items := []any{v}
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		items = make([]any, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+	}
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rows flattens records into cells. Without explicit columns, all fields
+// of all records are used in order of their first occurrence
+func rows(records []any, columns []string) ([]string, [][]string) {
+	flattened := make([]*object, len(records))
+	for i, r := range records {
+		flattened[i] = flatten(r)
+	}
+	if len(columns) == 0 {
+		seen := map[string]bool{}
+		for _, f := range flattened {
+			for _, k := range f.keys {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+	}
+	cells := make([][]string, len(flattened))
+	for i, f := range flattened {
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			row[j] = cell(f.values[c])
+		}
+		cells[i] = row
+	}
+	return columns, cells
+}
+
+func writeTable(w io.Writer, records []any, columns []string) error {
+	columns, cells := rows(records, columns)
+	if len(columns) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = strings.ToUpper(c)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range cells {
+		for i := range row {
+			row[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(row[i])
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, records []any, columns []string) error {
+	columns, cells := rows(records, columns)
+	if len(columns) == 0 {
+		return nil
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(cells); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	default:
+		bs, _ := json.Marshal(v)
+		return string(bs)
+	}
+}
+
+// project reduces a record to the given columns, keyed by their dotted path
+func project(record any, columns []string) any {
+	f := flatten(record)
+	o := &object{values: map[string]any{}}
+	for _, c := range columns {
+		o.keys = append(o.keys, c)
+		o.values[c] = f.values[c]
+	}
+	return o
+}
+
+// flatten converts nested objects into a single object with dotted keys.
+// Arrays are kept as values
+func flatten(v any) *object {
+	o := &object{values: map[string]any{}}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		nested, ok := v.(*object)
+		if !ok || (len(nested.keys) == 0 && prefix != "") {
+			o.keys = append(o.keys, prefix)
+			o.values[prefix] = v
+			return
+		}
+		for _, k := range nested.keys {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			walk(key, nested.values[k])
+		}
+	}
+	walk("", v)
+	return o
+}
+
+// object is a JSON object which remembers the order of its keys
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	b := bytes.Buffer{}
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	dec.UseNumber()
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(dec, t)
+}
+
+func decodeValue(dec *json.Decoder, t json.Token) (any, error) {
+	delim, ok := t.(json.Delim)
+	if !ok {
+		return t, nil
+	}
+	switch delim {
+	case '{':
+		o := &object{values: map[string]any{}}
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := kt.(string)
+			vt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec, vt)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := o.values[key]; !exists {
+				o.keys = append(o.keys, key)
+			}
+			o.values[key] = value
+		}
+		_, err := dec.Token()
+		return o, err
+	case '[':
+		list := []any{}
+		for dec.More() {
+			vt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec, vt)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return nil, fmt.Errorf("unexpected delimiter %v", delim)
+}
+
+// toYAML converts decoded values into yaml nodes, keeping the key order
+func toYAML(v any) *yaml.Node {
+	switch v := v.(type) {
+	case *object:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, k := range v.keys {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, toYAML(v.values[k]))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			n.Content = append(n.Content, toYAML(item))
+		}
+		return n
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprintf("%t", v)}
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(v)}
+	}
+}
+
+// Columns returns the dotted column names of v in order, e.g. to document them in help texts
+func Columns(v any) ([]string, error) {
+	records, _, err := toRecords(v)
+	if err != nil {
+		return nil, err
+	}
+	columns, _ := rows(records, nil)
+	return columns, nil
+}
@@ -0,0 +1,142 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type machine struct {
+	ID       string   `json:"id"`
+	Nickname string   `json:"nickName"`
+	Hours    float64  `json:"hours"`
+	Location location `json:"location"`
+	Tags     []string `json:"tags"`
+}
+
+var machines = []machine{
+	{ID: "1", Nickname: "North Barn BX", Hours: 12.5, Location: location{Latitude: 49.1, Longitude: -123.2}, Tags: []string{"a"}},
+	{ID: "2", Nickname: "true", Hours: 3},
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    any
+		opts     Options
+		expected string
+	}{
+		{
+			name:  "table with columns",
+			value: machines,
+			opts:  Options{Columns: []string{"id", "nickName", "location.latitude"}},
+			expected: `ID  NICKNAME       LOCATION.LATITUDE
+1   North Barn BX  49.1
+2   true           0
+`,
+		},
+		{
+			name:  "csv with all columns",
+			value: machines,
+			opts:  Options{Format: CSV},
+			expected: `id,nickName,hours,location.latitude,location.longitude,tags
+1,North Barn BX,12.5,49.1,-123.2,"[""a""]"
+2,true,3,0,0,
+`,
+		},
+		{
+			name:  "ndjson with columns",
+			value: machines,
+			opts:  Options{Format: NDJSON, Columns: []string{"id", "hours"}},
+			expected: `{"id":"1","hours":12.5}
+{"id":"2","hours":3}
+`,
+		},
+		{
+			name:  "json single value",
+			value: machines[1],
+			opts:  Options{Format: JSON, Columns: []string{"nickName"}},
+			expected: `{
+  "nickName": "true"
+}
+`,
+		},
+		{
+			name:  "yaml",
+			value: machines[1:],
+			opts:  Options{Format: YAML, Columns: []string{"id", "nickName", "hours", "tags"}},
+			expected: `- id: "2"
+  nickName: "true"
+  hours: 3
+  tags: null
+`,
+		},
+		{
+			name:     "template",
+			value:    machines,
+			opts:     Options{Template: `{{ .ID }} {{ .Nickname | upper }}`},
+			expected: "1 NORTH BARN BX\n2 TRUE\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := bytes.Buffer{}
+			if err := Write(&b, tt.value, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, b.String()); diff != "" {
+				t.Fatalf("unexpected output\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWrite_Empty(t *testing.T) {
+	t.Parallel()
+
+	for _, value := range []any{[]machine(nil), []machine{}} {
+		for format, expected := range map[string]string{
+			Table:  "",
+			CSV:    "",
+			JSON:   "[]\n",
+			NDJSON: "",
+			YAML:   "[]\n",
+		} {
+			b := bytes.Buffer{}
+			if err := Write(&b, value, Options{Format: format}); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != expected {
+				t.Errorf("expected %#v to render as %q in %s, got %q", value, expected, format, b.String())
+			}
+		}
+	}
+
+	b := bytes.Buffer{}
+	if err := Write(&b, []machine(nil), Options{Columns: []string{"id", "nickName"}}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "ID  NICKNAME\n"; b.String() != expected {
+		t.Fatalf("expected an empty table with headers, got %q", b.String())
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	if err := Write(&bytes.Buffer{}, machines, Options{Format: "xml"}); err == nil {
+		t.Fatal("expected unknown format to fail")
+	}
+}
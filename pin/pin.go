@@ -0,0 +1,176 @@
+// Package pin parses and validates Kubota product identification numbers (PINs) and serial numbers offline.
+//
+// PINs have 17 letters and digits, laid out similar to vehicle identification numbers (ISO 3779):
+// a 3 character manufacturer code, a 5 character machine descriptor, a check character,
+// the model year, the plant and a 6 character sequence number.
+// Kubota doesn't use the ISO 3779 check digit scheme, e.g. KBCDZ26CEN3K12345 is a valid PIN,
+// so the check digit is only reported by PIN.VerifyCheckDigit and never rejects a PIN.
+// Serial numbers are the numbers stamped on older machines and attachments
+package pin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Length of a PIN
+const Length = 17
+
+// Kind of an identifier
+type Kind string
+
+const (
+	KindInvalid Kind = "invalid"
+	KindPIN     Kind = "pin"
+	KindSerial  Kind = "serial"
+)
+
+var (
+	ErrEmpty      = errors.New("identifier is empty")
+	ErrLength     = fmt.Errorf("PIN must have %d characters", Length)
+	ErrCharacter  = errors.New("PIN contains invalid characters")
+	ErrCheckDigit = errors.New("PIN doesn't match the ISO 3779 check digit")
+)
+
+// PIN is a parsed product identification number
+type PIN struct {
+	// Manufacturer is the world manufacturer identifier, positions 1-3
+	Manufacturer string
+	// Descriptor identifies the machine type, positions 4-8
+	Descriptor string
+	CheckDigit byte
+	// YearCode encodes the model year, position 10. See ModelYear
+	YearCode byte
+	Plant    byte
+	// Sequence is the production sequence number, positions 12-17
+	Sequence string
+}
+
+// Normalize upper-cases s and removes spaces and dashes, which are common when PINs are typed in
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(s))
+}
+
+// transliteration maps PIN characters to their value in the check digit calculation.
+// I, O and Q are not used in PINs, because they are easily confused with 1 and 0
+var transliteration = map[byte]int{
+	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+var weights = [Length]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// CheckDigit calculates the ISO 3779 check digit of a normalized PIN. The current check digit of s is ignored.
+// Kubota PINs don't necessarily carry this check digit, see PIN.VerifyCheckDigit
+func CheckDigit(s string) (byte, error) {
+	if len(s) != Length {
+		return 0, ErrLength
+	}
+	sum := 0
+	for i := 0; i < Length; i++ {
+		v, ok := transliteration[s[i]]
+		if !ok {
+			return 0, fmt.Errorf("%w: %q at position %d", ErrCharacter, s[i], i+1)
+		}
+		sum += v * weights[i]
+	}
+	if sum%11 == 10 {
+		return 'X', nil
+	}
+	return byte('0' + sum%11), nil
+}
+
+// Parse parses a PIN. s is normalized first, and must have 17 letters and digits.
+// The check digit isn't validated, see VerifyCheckDigit
+func Parse(s string) (PIN, error) {
+	s = Normalize(s)
+	if s == "" {
+		return PIN{}, ErrEmpty
+	}
+	if len(s) != Length {
+		return PIN{}, ErrLength
+	}
+	for i := 0; i < Length; i++ {
+		if !isAlphanumeric(s[i]) {
+			return PIN{}, fmt.Errorf("%w: %q at position %d", ErrCharacter, s[i], i+1)
+		}
+	}
+	return PIN{
+		Manufacturer: s[0:3],
+		Descriptor:   s[3:8],
+		CheckDigit:   s[8],
+		YearCode:     s[9],
+		Plant:        s[10],
+		Sequence:     s[11:17],
+	}, nil
+}
+
+// VerifyCheckDigit reports whether the PIN carries the ISO 3779 check digit used by vehicle identification numbers.
+// Many Kubota PINs don't, so an error is informational only and doesn't mean the PIN is invalid
+func (p PIN) VerifyCheckDigit() error {
+	check, err := CheckDigit(p.String())
+	if err != nil {
+		return err
+	}
+	if p.CheckDigit != check {
+		return fmt.Errorf("%w: expected %c, got %c", ErrCheckDigit, check, p.CheckDigit)
+	}
+	return nil
+}
+
+// String returns the normalized PIN
+func (p PIN) String() string {
+	return fmt.Sprintf("%s%s%c%c%c%s", p.Manufacturer, p.Descriptor, p.CheckDigit, p.YearCode, p.Plant, p.Sequence)
+}
+
+// yearCodes repeat every 30 years, starting with A in 1980
+const yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// ModelYear returns the model year encoded in the PIN. Year codes repeat every 30 years,
+// so the latest year which is at most one year after reference is returned.
+// ok is false if the PIN doesn't encode a model year
+func (p PIN) ModelYear(reference time.Time) (year int, ok bool) {
+	idx := strings.IndexByte(yearCodes, p.YearCode)
+	if idx < 0 {
+		return 0, false
+	}
+	year = 1980 + idx
+	for year+30 <= reference.Year()+1 {
+		year += 30
+	}
+	return year, true
+}
+
+// Identify reports whether s, after normalization, has the shape of a PIN or a serial number.
+// PINs have 17 letters and digits, serial numbers any other number of letters and digits.
+// Identify doesn't verify check digits. KindInvalid only means s has neither shape,
+// the API may still know it, e.g. as serial number of an attachment
+func Identify(s string) Kind {
+	s = Normalize(s)
+	if s == "" {
+		return KindInvalid
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlphanumeric(s[i]) {
+			return KindInvalid
+		}
+	}
+	if len(s) == Length {
+		return KindPIN
+	}
+	return KindSerial
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,118 @@
+package pin
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	p, err := Parse("kbcdz26-cen3k12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Manufacturer != "KBC" || p.Descriptor != "DZ26C" || p.CheckDigit != 'E' || p.YearCode != 'N' || p.Plant != '3' || p.Sequence != "K12345" {
+		t.Fatalf("unexpected PIN %+v", p)
+	}
+	if p.String() != "KBCDZ26CEN3K12345" {
+		t.Fatalf("expected normalized PIN, got %s", p)
+	}
+
+	for _, tc := range []struct {
+		in  string
+		err error
+	}{
+		{"", ErrEmpty},
+		{"KBCDZ26CEN3K1234", ErrLength},
+		{"KBCDZ26CEN3K1234_", ErrCharacter},
+	} {
+		if _, err := Parse(tc.in); !errors.Is(err, tc.err) {
+			t.Errorf("expected %q to fail with %v, got %v", tc.in, tc.err, err)
+		}
+	}
+}
+
+func TestPIN_VerifyCheckDigit(t *testing.T) {
+	t.Parallel()
+
+	// Kubota PINs don't carry ISO 3779 check digits, which must not make them invalid
+	for _, in := range []string{"KBCDZ26CEN3K12345", "KBCKX040ZNK012345"} {
+		p, err := Parse(in)
+		if err != nil {
+			t.Fatalf("expected %s to parse, got %v", in, err)
+		}
+		if err := p.VerifyCheckDigit(); !errors.Is(err, ErrCheckDigit) {
+			t.Errorf("expected %s to not match the ISO 3779 check digit, got %v", in, err)
+		}
+	}
+
+	p, err := Parse("1M8GDM9AXKP042788")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.VerifyCheckDigit(); err != nil {
+		t.Fatalf("expected vehicle identification number to match its check digit, got %v", err)
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	t.Parallel()
+
+	for in, expected := range map[string]byte{
+		"11111111111111111": '1',
+		"1M8GDM9A0KP042788": 'X',
+		"KBCDZ26CEN3K12345": 'X',
+	} {
+		check, err := CheckDigit(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check != expected {
+			t.Errorf("expected check digit %c for %s, got %c", expected, in, check)
+		}
+	}
+	if _, err := CheckDigit("KBCDZ26CEN3K1234O"); !errors.Is(err, ErrCharacter) {
+		t.Fatalf("expected O to not be transliterated, got %v", err)
+	}
+}
+
+func TestPIN_ModelYear(t *testing.T) {
+	t.Parallel()
+
+	reference := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for code, expected := range map[byte]int{'A': 2010, 'K': 2019, 'T': 2026, 'V': 2027, 'W': 1998, '9': 2009} {
+		year, ok := PIN{YearCode: code}.ModelYear(reference)
+		if !ok || year != expected {
+			t.Errorf("expected year code %c to be %d, got %d", code, expected, year)
+		}
+	}
+	if _, ok := (PIN{YearCode: 'U'}).ModelYear(reference); ok {
+		t.Error("expected U to not encode a model year")
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	t.Parallel()
+
+	for in, expected := range map[string]Kind{
+		"KBCDZ26CEN3K12345":   KindPIN,
+		"KBC DZ26C EN3K12345": KindPIN,
+		"KBCKX040ZNK012345":   KindPIN,
+		"30123":               KindSerial,
+		"ab12345":             KindSerial,
+		"12345678901":         KindSerial,
+		"A123456789012":       KindSerial,
+		"":                    KindInvalid,
+		"KBCDZ26CEN3K1234_":   KindInvalid,
+		"30_123":              KindInvalid,
+		// attachment serial numbers may contain other characters; AddEquipment sends them anyway
+		"B1630/0012": KindInvalid,
+		"50123-A":    KindSerial,
+	} {
+		if kind := Identify(in); kind != expected {
+			t.Errorf("expected %q to be %s, got %s", in, expected, kind)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mykubota
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// MaintenanceSchedulesOptions configures bulk maintenance schedule downloads
+type MaintenanceSchedulesOptions struct {
+	// Concurrency limits the number of parallel requests. Defaults to 10
+	Concurrency int
+	// Progress is called once per model after its request finished. Calls are never concurrent
+	Progress func(MaintenanceSchedulesProgress)
+}
+
+// MaintenanceSchedulesProgress describes the outcome of a single model during a bulk download
+type MaintenanceSchedulesProgress struct {
+	Model string
+	Err   error
+	Done  int
+	Total int
+}
+
+// MaintenanceSchedulesResult contains the schedules of all successful models, and errors for all others
+type MaintenanceSchedulesResult struct {
+	Schedules map[string][]Maintenance
+	Errors    map[string]error
+}
+
+// MaintenanceSchedules fetches the maintenance schedules of many models with bounded parallelism.
+// Failing models are reported in the result instead of aborting the download.
+// If ctx is cancelled, the partial result is returned alongside the context error
+func (c *Client) MaintenanceSchedules(ctx context.Context, models []string, opts MaintenanceSchedulesOptions) (*MaintenanceSchedulesResult, error) {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 10
+	}
+
+	res := &MaintenanceSchedulesResult{
+		Schedules: map[string][]Maintenance{},
+		Errors:    map[string]error{},
+	}
+	mu := sync.Mutex{}
+	done := 0
+
+	g := errgroup.Group{}
+	g.SetLimit(concurrency)
+	for _, model := range models {
+		if ctx.Err() != nil {
+			break
+		}
+		model := model
+		g.Go(func() error {
+			schedule, err := c.maintenanceSchedule(ctx, model)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				res.Errors[model] = err
+			} else {
+				res.Schedules[model] = schedule
+			}
+			done++
+			if opts.Progress != nil {
+				opts.Progress(MaintenanceSchedulesProgress{
+					Model: model,
+					Err:   err,
+					Done:  done,
+					Total: len(models),
+				})
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return res, ctx.Err()
+}
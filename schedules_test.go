@@ -0,0 +1,91 @@
+package mykubota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_MaintenanceSchedules(t *testing.T) {
+	t.Parallel()
+
+	var inflight, maxInflight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInflight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInflight, max, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		model := strings.TrimPrefix(r.URL.Path, "/api/maintenanceSchedule/")
+		if strings.HasPrefix(model, "broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `[{"id":"%s-1","checkPoint":"Engine oil","intervalValue":250}]`, model)
+	}))
+	defer srv.Close()
+
+	models := []string{}
+	for i := 0; i < 20; i++ {
+		models = append(models, fmt.Sprintf("KX0%d", i))
+	}
+	models = append(models, "broken-1", "broken-2")
+
+	progress := 0
+	res, err := New("en-CA", WithEndpoint(srv.URL)).MaintenanceSchedules(context.Background(), models, MaintenanceSchedulesOptions{
+		Concurrency: 3,
+		Progress: func(p MaintenanceSchedulesProgress) {
+			progress++
+			if p.Done != progress || p.Total != len(models) {
+				t.Errorf("unexpected progress %+v after %d calls", p, progress)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Schedules) != 20 {
+		t.Fatalf("expected 20 schedules, got %d", len(res.Schedules))
+	}
+	if len(res.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", res.Errors)
+	}
+	if _, ok := res.Schedules["broken-1"]; ok {
+		t.Fatal("expected failing models to be absent from schedules")
+	}
+	if progress != len(models) {
+		t.Fatalf("expected %d progress callbacks, got %d", len(models), progress)
+	}
+	if max := atomic.LoadInt32(&maxInflight); max > 3 {
+		t.Fatalf("expected at most 3 parallel requests, got %d", max)
+	}
+}
+
+func TestClient_MaintenanceSchedulesCancelled(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := New("en-CA", WithEndpoint(srv.URL)).MaintenanceSchedules(ctx, []string{"KX040-4"}, MaintenanceSchedulesOptions{})
+	if err != context.Canceled {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+	if res == nil {
+		t.Fatal("expected partial result on cancellation")
+	}
+}
@@ -0,0 +1,238 @@
+package mykubota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaDrift lists the differences between the responses of an endpoint and the types they are decoded into
+type SchemaDrift struct {
+	// Endpoint is the method and path of the request, with IDs and models replaced by placeholders
+	Endpoint string `json:"endpoint"`
+	// Unknown fields are sent by the API but not mapped. Paths are joined by dots, [] marks list elements
+	Unknown []string `json:"unknown"`
+	// Missing fields are mapped but were absent from at least one response. omitempty fields are never missing
+	Missing []string `json:"missing"`
+}
+
+// SchemaReport collects schema drift of all decoded responses. It is safe for concurrent use
+type SchemaReport struct {
+	mu        sync.Mutex
+	endpoints map[string]*schemaFields
+}
+
+type schemaFields struct {
+	unknown map[string]bool
+	missing map[string]bool
+}
+
+// WithSchemaReport enables strict decoding: every decoded response is compared with the
+// type it is decoded into, and unknown or missing fields are recorded in report
+func WithSchemaReport(report *SchemaReport) Option {
+	return func(c *Client) {
+		c.schema = report
+	}
+}
+
+// Drifts returns the drift of all endpoints with unknown or missing fields, sorted by endpoint
+func (r *SchemaReport) Drifts() []SchemaDrift {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	drifts := []SchemaDrift{}
+	for endpoint, fields := range r.endpoints {
+		if len(fields.unknown) == 0 && len(fields.missing) == 0 {
+			continue
+		}
+		drifts = append(drifts, SchemaDrift{
+			Endpoint: endpoint,
+			Unknown:  sortedKeys(fields.unknown),
+			Missing:  sortedKeys(fields.missing),
+		})
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Endpoint < drifts[j].Endpoint
+	})
+	return drifts
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// check records the fields of raw which don't match the type of v
+func (r *SchemaReport) check(endpoint string, raw []byte, v any) {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return
+	}
+	fields := &schemaFields{unknown: map[string]bool{}, missing: map[string]bool{}}
+	compareSchema(fields, "", doc, reflect.TypeOf(v))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.endpoints == nil {
+		r.endpoints = map[string]*schemaFields{}
+	}
+	existing, ok := r.endpoints[endpoint]
+	if !ok {
+		r.endpoints[endpoint] = fields
+		return
+	}
+	for k := range fields.unknown {
+		existing.unknown[k] = true
+	}
+	for k := range fields.missing {
+		existing.missing[k] = true
+	}
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if name == "[]" {
+		return path + name
+	}
+	return path + "." + name
+}
+
+// lookupField returns the value of the key matching name the way encoding/json does:
+// an exact match is preferred, otherwise keys are compared case-insensitively like in extra.go
+func lookupField(obj map[string]any, name string) (any, bool) {
+	if value, ok := obj[name]; ok {
+		return value, true
+	}
+	for key, value := range obj {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// compareSchema walks the decoded JSON document alongside the type it is decoded into
+func compareSchema(fields *schemaFields, path string, doc any, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || (t.Kind() != reflect.Struct && reflect.PtrTo(t).Implements(unmarshalerType)) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return
+		}
+		known := map[string]bool{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = f.Name
+			}
+			known[strings.ToLower(name)] = true
+			value, ok := lookupField(obj, name)
+			if !ok {
+				if !strings.Contains(opts, "omitempty") {
+					fields.missing[joinPath(path, name)] = true
+				}
+				continue
+			}
+			compareSchema(fields, joinPath(path, name), value, f.Type)
+		}
+		for name := range obj {
+			if !known[strings.ToLower(name)] {
+				fields.unknown[joinPath(path, name)] = true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := doc.([]any)
+		if !ok {
+			return
+		}
+		for _, item := range list {
+			compareSchema(fields, joinPath(path, "[]"), item, t.Elem())
+		}
+	case reflect.Map:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, value := range obj {
+			compareSchema(fields, joinPath(path, "*"), value, t.Elem())
+		}
+	}
+}
+
+type schemaReportKey struct{}
+
+// withSchemaReport makes the report available to response processors of requests using ctx
+func withSchemaReport(ctx context.Context, report *SchemaReport) context.Context {
+	if report == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, schemaReportKey{}, report)
+}
+
+// decodeJSON decodes the response body into v, recording schema drift if enabled for the request
+func decodeJSON(resp *http.Response, v any) error {
+	var report *SchemaReport
+	if resp.Request != nil {
+		report, _ = resp.Request.Context().Value(schemaReportKey{}).(*SchemaReport)
+	}
+	if report == nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(bytes.NewReader(bs)).Decode(v); err != nil {
+		return err
+	}
+	report.check(endpointName(resp.Request), bs, v)
+	return nil
+}
+
+// endpointName returns method and path of the request, replacing equipment IDs and models
+func endpointName(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i := 1; i < len(segments); i++ {
+		switch segments[i-1] {
+		case "equipment":
+			if segments[i] != "update" && segments[i] != "addFromScan" {
+				segments[i] = "{id}"
+			}
+		case "maintenanceSchedule":
+			segments[i] = "{model}"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
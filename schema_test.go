@@ -0,0 +1,94 @@
+package mykubota
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithSchemaReport(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"1","checkPoint":"Engine oil","measures":"Change","firstCheckValue":50,"displayIntervalType":"Hours","intervalType":"Hours","intervalValue":250,"iconUrl":"https://example.com/oil.png"},
+			{"id":"2","checkPoint":"Grease","measures":"Apply","firstCheckValue":0,"displayIntervalType":"Hours","intervalType":"Hours","intervalValue":50,"sortOrder":2}]`))
+	}))
+	defer srv.Close()
+
+	report := &SchemaReport{}
+	client := New("en-CA", WithEndpoint(srv.URL), WithSchemaReport(report))
+	for _, model := range []string{"KX040-4", "SVL97-2"} {
+		if _, err := client.MaintenanceSchedule(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []SchemaDrift{{
+		Endpoint: "GET /api/maintenanceSchedule/{model}",
+		Unknown:  []string{"[].iconUrl"},
+		Missing:  []string{"[].sortOrder"},
+	}}
+	if diff := cmp.Diff(expected, report.Drifts()); diff != "" {
+		t.Fatalf("unexpected drift\n%s", diff)
+	}
+}
+
+func TestSchemaReport_Nested(t *testing.T) {
+	t.Parallel()
+
+	report := &SchemaReport{}
+	report.check("GET /api/user/equipment/{id}", []byte(`{
+		"id": "1", "model": "KX040-4", "categoryId": 1, "category": "", "subcategory": "", "identifierType": "Pin",
+		"type": "machine", "pinOrSerial": "1", "pin": "1", "serial": "", "nickName": "", "userEnteredEngineHours": 0,
+		"hasTelematics": true, "hasFaultCodes": false, "hasMaintenanceSchedules": true, "manualEntries": [{"name": "Manual", "url": "", "language": "en"}],
+		"videoEntries": [], "dealerName": "Kubota Vancouver",
+		"telematics": {"locationTime": "2022-07-01T12:00:00Z", "location": {"latitude": 1, "longitude": 2, "altitudeMeters": 0, "positionHeadingAngle": 0, "accuracy": 5}}
+	}`), &Equipment{})
+
+	drifts := report.Drifts()
+	if len(drifts) != 1 {
+		t.Fatalf("expected drift of a single endpoint, got %v", drifts)
+	}
+	if diff := cmp.Diff([]string{"dealerName", "manualEntries[].language", "telematics.location.accuracy"}, drifts[0].Unknown); diff != "" {
+		t.Fatalf("unexpected unknown fields\n%s", diff)
+	}
+	missing := map[string]bool{}
+	for _, field := range drifts[0].Missing {
+		missing[field] = true
+	}
+	if missing["id"] || missing["telematics.locationTime"] || !missing["telematics.airInletTempCelsius"] {
+		t.Fatalf("unexpected missing fields %v", drifts[0].Missing)
+	}
+}
+
+func TestEndpointName(t *testing.T) {
+	t.Parallel()
+
+	for path, expected := range map[string]string{
+		"/api/user/equipment":                        "GET /api/user/equipment",
+		"/api/user/equipment/update":                 "GET /api/user/equipment/update",
+		"/api/user/equipment/abc":                    "GET /api/user/equipment/{id}",
+		"/api/user/equipment/abc/maintenanceHistory": "GET /api/user/equipment/{id}/maintenanceHistory",
+		"/api/maintenanceSchedule/KX040-4":           "GET /api/maintenanceSchedule/{model}",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		if name := endpointName(req); name != expected {
+			t.Errorf("expected %s for %s, got %s", expected, path, name)
+		}
+	}
+}
+
+func TestSchemaReport_CaseInsensitive(t *testing.T) {
+	t.Parallel()
+
+	report := &SchemaReport{}
+	report.check("GET /api/maintenanceSchedule/{model}", []byte(`[{
+		"ID": "1", "checkpoint": "Engine oil", "Measures": "Change", "firstCheckValue": 50,
+		"displayintervaltype": "Hours", "intervalType": "Hours", "intervalValue": 250, "sortOrder": 1
+	}]`), &[]Maintenance{})
+
+	if drifts := report.Drifts(); len(drifts) != 0 {
+		t.Fatalf("expected keys differing only in case to match like encoding/json does, got %v", drifts)
+	}
+}
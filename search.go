@@ -0,0 +1,126 @@
+package mykubota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nicolai86/mykubota/pin"
+)
+
+// MatchReason explains why a model is a candidate of a machine search
+type MatchReason string
+
+const (
+	// MatchModelExact means the model name equals the partial model, ignoring case and punctuation
+	MatchModelExact MatchReason = "model-exact"
+	// MatchModelPrefix means the model name starts with the partial model
+	MatchModelPrefix MatchReason = "model-prefix"
+	// MatchRegistry means Kubotas registry returned the model for the PIN or serial
+	MatchRegistry MatchReason = "registry"
+	// MatchModelSubstring means the model name contains the partial model
+	MatchModelSubstring MatchReason = "model-substring"
+)
+
+// matchScores rank candidates. A candidate scores the sum of all its matches
+var matchScores = map[MatchReason]int{
+	MatchModelExact:     100,
+	MatchModelPrefix:    50,
+	MatchRegistry:       40,
+	MatchModelSubstring: 20,
+}
+
+// MachineMatch is a single reason why a model matched
+type MachineMatch struct {
+	Reason MatchReason `json:"reason"`
+	Detail string      `json:"detail"`
+}
+
+// MachineCandidate is a model which might be the machine searched for
+type MachineCandidate struct {
+	Model   Model          `json:"model"`
+	Score   int            `json:"score"`
+	Matches []MachineMatch `json:"matches"`
+}
+
+// SearchMachines returns all models which might match request, best candidates first.
+// Serial may be a PIN or a serial number, which is normalized and looked up in Kubotas registry.
+// PINs aren't validated offline, because Kubota PINs don't follow a verifiable scheme.
+// Candidates with the same score are sorted by model name
+func (c *Client) SearchMachines(ctx context.Context, request SearchMachineRequest) ([]MachineCandidate, error) {
+	partial := normalizeModel(request.PartialModel)
+	identifier := pin.Normalize(request.Serial)
+	if partial == "" && identifier == "" {
+		return nil, errors.New("missing partial model, PIN or serial")
+	}
+	candidates := map[string]*MachineCandidate{}
+	order := []string{}
+	add := func(m Model, reason MatchReason, detail string) {
+		candidate, ok := candidates[m.Model]
+		if !ok {
+			candidate = &MachineCandidate{Model: m}
+			candidates[m.Model] = candidate
+			order = append(order, m.Model)
+		}
+		for _, match := range candidate.Matches {
+			if match.Reason == reason {
+				return
+			}
+		}
+		candidate.Score += matchScores[reason]
+		candidate.Matches = append(candidate.Matches, MachineMatch{Reason: reason, Detail: detail})
+	}
+
+	if identifier != "" {
+		models, err := c.searchRegistry(ctx, SearchMachineRequest{PartialModel: request.PartialModel, Serial: identifier})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range models {
+			add(m, MatchRegistry, fmt.Sprintf("Kubotas registry lists %s for %s", m.Model, identifier))
+		}
+	}
+
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		name := normalizeModel(m.Model)
+		switch {
+		case partial == "":
+		case name == partial:
+			add(m, MatchModelExact, fmt.Sprintf("model name is %s", request.PartialModel))
+		case strings.HasPrefix(name, partial):
+			add(m, MatchModelPrefix, fmt.Sprintf("model name starts with %s", request.PartialModel))
+		case strings.Contains(name, partial):
+			add(m, MatchModelSubstring, fmt.Sprintf("model name contains %s", request.PartialModel))
+		}
+	}
+
+	res := make([]MachineCandidate, 0, len(order))
+	for _, name := range order {
+		res = append(res, *candidates[name])
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].Model.Model < res[j].Model.Model
+	})
+	return res, nil
+}
+
+// normalizeModel upper-cases name and removes everything but letters and digits,
+// so KX040-4, kx 040 4 and KX0404 compare equal
+func normalizeModel(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z':
+			return r
+		}
+		return -1
+	}, strings.ToUpper(name))
+}
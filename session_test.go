@@ -0,0 +1,440 @@
+package mykubota_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolai86/mykubota"
+	"github.com/nicolai86/mykubota/mykubotatest"
+	"github.com/nicolai86/mykubota/pin"
+)
+
+func newFakeSession(t *testing.T) (*mykubotatest.Server, *mykubota.Session) {
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	return srv, srv.Session(t, mykubotatest.DefaultUsername)
+}
+
+func TestFakeSession_Equipment(t *testing.T) {
+	t.Parallel()
+
+	srv, session := newFakeSession(t)
+	ctx := context.Background()
+	eqs, err := session.ListEquipment(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eqs) != 2 {
+		t.Fatalf("expected 2 equipment, got %d", len(eqs))
+	}
+
+	updated, err := session.UpdateEquipment(ctx, mykubota.UpdateEquipmentRequest{EquipmentID: eqs[1].ID, EngineHours: 120, NickName: "Mower"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Nickname != "Mower" || updated.UserEnteredEngineHours != 120 {
+		t.Fatalf("unexpected update result %+v", updated)
+	}
+	req := srv.AssertRequested(t, http.MethodPut, "/api/user/equipment/update")
+	if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected json request, got %q", ct)
+	}
+	if expected := `{"id":"` + eqs[1].ID + `","engineHours":120,"nickName":"Mower"}` + "\n"; string(req.Body) != expected {
+		t.Fatalf("expected only mapped fields to be sent\nexpected %s\ngot      %s", expected, req.Body)
+	}
+
+	eq, err := session.GetEquipment(ctx, eqs[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq.Nickname != "Mower" {
+		t.Fatalf("expected update to persist, got %+v", eq)
+	}
+
+	if err := session.DeleteEquipment(ctx, eqs[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.GetEquipment(ctx, eqs[1].ID); err == nil {
+		t.Fatal("expected deleted equipment to be gone")
+	}
+}
+
+func TestFakeSession_AddEquipment(t *testing.T) {
+	t.Parallel()
+
+	srv, session := newFakeSession(t)
+	if err := session.AddEquipment(context.Background(), mykubota.AddEquipmentRequest{
+		Model:       &mykubota.Model{Model: "KX057-4"},
+		PinOrSerial: "30123",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req := srv.AssertRequested(t, http.MethodPost, "/api/user/equipment/addFromScan")
+	body := map[string]any{}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["model"] != "KX057-4" || body["pinOrSerial"] != "30123" || body["identifierType"] != "Serial" {
+		t.Fatalf("unexpected request body %s", req.Body)
+	}
+
+	for input, expected := range map[string]string{
+		"kbcdz26-cen3k12345": "KBCDZ26CEN3K12345",
+		"KBCKX040ZNK012345":  "KBCKX040ZNK012345",
+	} {
+		srv.Reset()
+		if err := session.AddEquipment(context.Background(), mykubota.AddEquipmentRequest{
+			Model:       &mykubota.Model{Model: "KX057-4"},
+			PinOrSerial: input,
+		}); err != nil {
+			t.Fatalf("expected %q to be added, got %v", input, err)
+		}
+		req = srv.AssertRequested(t, http.MethodPost, "/api/user/equipment/addFromScan")
+		body = map[string]any{}
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["pinOrSerial"] != expected || body["identifierType"] != "Pin" {
+			t.Fatalf("expected normalized PIN %s, got %s", expected, req.Body)
+		}
+	}
+
+	srv.Reset()
+	if err := session.AddEquipment(context.Background(), mykubota.AddEquipmentRequest{
+		Model:       &mykubota.Model{Model: "KX057-4"},
+		PinOrSerial: "A123456789012",
+	}); err != nil {
+		t.Fatalf("expected long serial numbers to be accepted, got %v", err)
+	}
+	req = srv.AssertRequested(t, http.MethodPost, "/api/user/equipment/addFromScan")
+	if !strings.Contains(string(req.Body), `"identifierType":"Serial"`) {
+		t.Fatalf("expected serial number, got %s", req.Body)
+	}
+
+	// serial numbers stamped on attachments contain other characters, and are left for the API to check
+	for _, serial := range []string{"30_123", "B1630/0012"} {
+		srv.Reset()
+		if err := session.AddEquipment(context.Background(), mykubota.AddEquipmentRequest{
+			Model:       &mykubota.Model{Model: "KX057-4"},
+			PinOrSerial: serial,
+		}); err != nil {
+			t.Fatalf("expected %q to be sent, got %v", serial, err)
+		}
+		req = srv.AssertRequested(t, http.MethodPost, "/api/user/equipment/addFromScan")
+		body = map[string]any{}
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["pinOrSerial"] != serial || body["identifierType"] != "Serial" {
+			t.Fatalf("expected unchanged serial number %s, got %s", serial, req.Body)
+		}
+	}
+
+	srv.Reset()
+	if err := session.AddEquipment(context.Background(), mykubota.AddEquipmentRequest{
+		Model:       &mykubota.Model{Model: "KX057-4"},
+		PinOrSerial: " ",
+	}); !errors.Is(err, pin.ErrEmpty) {
+		t.Fatalf("expected an empty identifier to be rejected, got %v", err)
+	}
+	srv.AssertNotRequested(t, http.MethodPost, "/api/user/equipment/addFromScan")
+}
+
+func TestFakeSession_Maintenance(t *testing.T) {
+	t.Parallel()
+
+	_, session := newFakeSession(t)
+	eqs, err := session.ListEquipment(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.RecordMaintenance(eqs[0].ID, mykubota.MaintenanceHistory{
+		IntervalType:         "Every X Hours",
+		IntervalValue:        500,
+		CompletedEngineHours: 502,
+		MaintenanceCheckList: map[string]bool{"kx040-4-1": true, "kx040-4-2": true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	history, err := session.MaintenanceHistory(eqs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].IntervalValue != 500 || history[1].ID == "" {
+		t.Fatalf("unexpected maintenance history %+v", history)
+	}
+}
+
+func TestFakeSession_UpdateEquipment(t *testing.T) {
+	t.Parallel()
+
+	h := mykubotatest.NewHandler(mykubotatest.DefaultFixtures())
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/user/equipment/update" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	session, err := mykubota.New("en-CA", mykubota.WithEndpoint(srv.URL)).Authenticate(ctx, mykubotatest.DefaultUsername, mykubotatest.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = session.UpdateEquipment(ctx, mykubota.UpdateEquipmentRequest{
+		EquipmentID: "1",
+		NickName:    "Digger",
+		Extra:       map[string]json.RawMessage{"fleetNumber": json.RawMessage(`7`)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "returned no equipment") {
+		t.Fatalf("expected an empty response to fail, got %v", err)
+	}
+	if expected := `{"id":"1","engineHours":0,"nickName":"Digger","fleetNumber":7}` + "\n"; string(body) != expected {
+		t.Fatalf("expected explicitly set extra fields to be sent\nexpected %s\ngot      %s", expected, body)
+	}
+}
+
+func TestFakeSession_Settings(t *testing.T) {
+	t.Parallel()
+
+	srv, session := newFakeSession(t)
+	srv.Seed(func(f *mykubotatest.Fixtures) {
+		f.Accounts[0].Settings.MeasurementUnit = "imperial"
+	})
+	settings, err := session.Settings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.MeasurementUnit != "imperial" {
+		t.Fatalf("expected imperial settings, got %+v", settings)
+	}
+}
+
+func TestFakeClient_Catalog(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	client := srv.NewClient("en-CA")
+	ctx := context.Background()
+
+	roots, err := client.GetModelTree(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[0].ModelCount() != 2 {
+		t.Fatalf("unexpected model tree %+v", roots)
+	}
+
+	model, err := client.SearchMachine(ctx, mykubota.SearchMachineRequest{PartialModel: "kx05", Serial: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Model != "KX057-4" {
+		t.Fatalf("expected KX057-4, got %q", model.Model)
+	}
+
+	schedule, err := client.MaintenanceSchedule("KX040-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule) != 2 {
+		t.Fatalf("expected 2 check points, got %d", len(schedule))
+	}
+	if req := srv.AssertRequested(t, http.MethodGet, "/api/maintenanceSchedule/KX040-4"); req.Header.Get("Accept-Language") != "en-CA" {
+		t.Fatalf("expected locale header, got %q", req.Header.Get("Accept-Language"))
+	}
+}
+
+func TestFakeSession_WarrantyCoverage(t *testing.T) {
+	t.Parallel()
+
+	docs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no token to be sent to %s", r.Host)
+		}
+		switch r.URL.Path {
+		case "/active.json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			fmt.Fprintf(w, `{"plan": "Standard", "startDate": "2022-01-01", "endDate": %q, "maxHours": 2000}`, time.Now().AddDate(1, 0, 0).Format("2006-01-02"))
+		case "/hours.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"plan": "Powertrain", "endDate": "2099-12-31", "maxHours": 100}`))
+		case "/warranty.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer docs.Close()
+
+	srv, session := newFakeSession(t)
+	srv.Seed(func(f *mykubotatest.Fixtures) {
+		eqs := f.Accounts[0].Equipment
+		eqs[0].WarrantyURL = docs.URL + "/active.json"
+		eqs[1].WarrantyURL = docs.URL + "/hours.json"
+		eqs[1].UserEnteredEngineHours = 150
+		pdf, missing, none := eqs[1], eqs[1], eqs[1]
+		pdf.ID, pdf.WarrantyURL = "pdf", docs.URL+"/warranty.pdf"
+		missing.ID, missing.WarrantyURL = "missing", docs.URL+"/missing.json"
+		none.ID, none.WarrantyURL = "none", ""
+		f.Accounts[0].Equipment = append(eqs, pdf, missing, none)
+	})
+
+	coverage, err := session.WarrantyCoverage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coverage) != 5 {
+		t.Fatalf("expected coverage of 5 machines, got %d", len(coverage))
+	}
+	expected := []mykubota.WarrantyStatus{mykubota.WarrantyActive, mykubota.WarrantyExpired, mykubota.WarrantyUnknown, mykubota.WarrantyUnknown, mykubota.WarrantyNone}
+	for i, c := range coverage {
+		if c.Status != expected[i] {
+			t.Errorf("expected %s to be %s, got %s (%v)", c.EquipmentID, expected[i], c.Status, c.Err)
+		}
+	}
+	if coverage[0].Terms == nil || coverage[0].Terms.Plan != "Standard" || coverage[0].EngineHours == 0 {
+		t.Fatalf("expected structured terms and telematics hours, got %+v", coverage[0])
+	}
+	if coverage[2].Terms != nil || coverage[2].Err != nil {
+		t.Fatalf("expected unstructured document without error, got %+v", coverage[2])
+	}
+	statusErr := &mykubota.StatusError{}
+	if !errors.As(coverage[3].Err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected not found error, got %v", coverage[3].Err)
+	}
+	bs, err := json.Marshal(coverage[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bs), `"error":"`) || !strings.Contains(string(bs), `"status":"unknown"`) {
+		t.Fatalf("expected the error to be encoded, got %s", bs)
+	}
+}
+
+func TestFakeClient_SearchMachines(t *testing.T) {
+	t.Parallel()
+
+	srv := mykubotatest.NewServer(t, mykubotatest.DefaultFixtures())
+	client := srv.NewClient("en-CA")
+	ctx := context.Background()
+
+	models := func(candidates []mykubota.MachineCandidate) []string {
+		names := []string{}
+		for _, c := range candidates {
+			names = append(names, c.Model.Model)
+		}
+		return names
+	}
+
+	candidates, err := client.SearchMachines(ctx, mykubota.SearchMachineRequest{PartialModel: "kx0", Serial: "30123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models(candidates); len(got) != 2 || got[0] != "KX040-4" || got[1] != "KX057-4" {
+		t.Fatalf("expected both excavators, got %v", got)
+	}
+	if m := candidates[0].Matches; len(m) != 2 || m[0].Reason != mykubota.MatchRegistry || m[1].Reason != mykubota.MatchModelPrefix {
+		t.Fatalf("expected registry and prefix match, got %+v", m)
+	}
+	if req := srv.Requests()[0]; req.Path != "/api/models" || req.Query.Get("serial") != "30123" {
+		t.Fatalf("expected serial to be sent to the registry, got %s %v", req.Path, req.Query)
+	}
+
+	candidates, err = client.SearchMachines(ctx, mykubota.SearchMachineRequest{PartialModel: "kx 057 4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].Matches[0].Reason != mykubota.MatchModelExact {
+		t.Fatalf("expected exact match, got %+v", candidates)
+	}
+
+	// PINs are never rejected offline, the registry decides which models they belong to
+	srv.Reset()
+	candidates, err = client.SearchMachines(ctx, mykubota.SearchMachineRequest{PartialModel: "KX040", Serial: "kbcdz26-cen3k12345"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models(candidates); len(got) != 1 || got[0] != "KX040-4" {
+		t.Fatalf("expected KX040-4, got %v", got)
+	}
+	if m := candidates[0].Matches; len(m) != 2 || m[0].Reason != mykubota.MatchRegistry || m[1].Reason != mykubota.MatchModelPrefix {
+		t.Fatalf("expected registry and prefix match, got %+v", m)
+	}
+	if req := srv.Requests()[0]; req.Query.Get("serial") != "KBCDZ26CEN3K12345" {
+		t.Fatalf("expected normalized PIN to be sent to the registry, got %v", req.Query)
+	}
+}
+
+func TestFakeSession_GetEquipmentBatch(t *testing.T) {
+	t.Parallel()
+
+	srv, session := newFakeSession(t)
+	ctx := context.Background()
+	eqs, err := session.ListEquipment(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.InjectFault(mykubotatest.Fault{Method: http.MethodGet, Path: "/api/user/equipment/*", Kind: mykubotatest.FaultStatus, Status: http.StatusTooManyRequests, RetryAfter: time.Second, Times: 1})
+
+	progress := 0
+	res, err := session.GetEquipmentBatch(ctx, []string{eqs[0].ID, eqs[1].ID, "unknown", eqs[0].ID}, mykubota.GetEquipmentBatchOptions{
+		Concurrency:       2,
+		RequestsPerSecond: 50,
+		Progress:          func(mykubota.GetEquipmentBatchProgress) { progress++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Equipment) != 2 || res.Equipment[eqs[1].ID].Model != eqs[1].Model {
+		t.Fatalf("expected both machines, got %+v", res.Equipment)
+	}
+	statusErr := &mykubota.StatusError{}
+	if len(res.Errors) != 1 || !errors.As(res.Errors["unknown"], &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected not found error for unknown ID, got %v", res.Errors)
+	}
+	if progress != 3 {
+		t.Fatalf("expected progress for each unique ID, got %d", progress)
+	}
+	details := 0
+	for _, req := range srv.Requests() {
+		if strings.HasPrefix(req.Path, "/api/user/equipment/") {
+			details++
+		}
+	}
+	if details != 4 {
+		t.Fatalf("expected one request per unique ID and a retry after the rate limit, got %d", details)
+	}
+}
+
+func TestFakeSession_FindEquipment(t *testing.T) {
+	t.Parallel()
+
+	_, session := newFakeSession(t)
+	ctx := context.Background()
+	for _, query := range []string{"digger", "KBCDZ26CEN3K12345", "8f7c1a52-54b5-4a8c-9a3e-2c6b3a1f0e01", "kx040-4"} {
+		eq, err := session.FindEquipment(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eq.Nickname != "Digger" {
+			t.Fatalf("expected %q to find the excavator, got %+v", query, eq)
+		}
+	}
+	if _, err := session.FindEquipment(ctx, "mower"); !errors.Is(err, mykubota.ErrEquipmentNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package mykubota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrNoToken is returned by token stores which don't contain a token yet
+var ErrNoToken = errors.New("no token stored")
+
+// TokenStore persists oauth tokens across processes
+type TokenStore interface {
+	Token() (*oauth2.Token, error)
+	SetToken(*oauth2.Token) error
+}
+
+// FileTokenStore stores a token as JSON file, readable only by the current user
+type FileTokenStore struct {
+	Path string
+}
+
+// Token reads the stored token, returning ErrNoToken if the file doesn't exist
+func (s *FileTokenStore) Token() (*oauth2.Token, error) {
+	bs, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := oauth2.Token{}
+	if err := json.Unmarshal(bs, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SetToken replaces the stored token
+func (s *FileTokenStore) SetToken(t *oauth2.Token) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, bs, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+// SessionFromTokenStore restores a session from the token in store, like SessionFromToken.
+// Whenever the token is refreshed the new token is written back to store
+func (c *Client) SessionFromTokenStore(ctx context.Context, store TokenStore) (*Session, error) {
+	t, err := store.Token()
+	if err != nil {
+		return nil, err
+	}
+	return c.newSession(ctx, t, store), nil
+}
+
+// reuseTokenSource returns the current token until it expires, like oauth2.ReuseTokenSource.
+// Unlike it, the token can be expired early when the API rejects it
+type reuseTokenSource struct {
+	config *oauth2.Config
+	ctx    context.Context
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func (s *reuseTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	t, err := s.config.TokenSource(s.ctx, &oauth2.Token{RefreshToken: s.token.RefreshToken}).Token()
+	if err != nil {
+		return nil, err
+	}
+	s.token = t
+	return t, nil
+}
+
+// expire forces a refresh on the next call to Token
+func (s *reuseTokenSource) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expired := *s.token
+	expired.AccessToken = ""
+	s.token = &expired
+}
+
+// storingTokenSource writes every new token returned by base to store
+type storingTokenSource struct {
+	base  oauth2.TokenSource
+	store TokenStore
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (s *storingTokenSource) Token() (*oauth2.Token, error) {
+	t, err := s.base.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil || s.last.AccessToken != t.AccessToken {
+		if err := s.store.SetToken(t); err != nil {
+			return nil, err
+		}
+		s.last = t
+	}
+	return t, nil
+}
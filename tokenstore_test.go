@@ -0,0 +1,78 @@
+package mykubota
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	t.Parallel()
+
+	store := &FileTokenStore{Path: filepath.Join(t.TempDir(), "tokens", "default.json")}
+	if _, err := store.Token(); !errors.Is(err, ErrNoToken) {
+		t.Fatalf("expected missing token error, got %v", err)
+	}
+
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	if err := store.SetToken(&oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: expiry}); err != nil {
+		t.Fatal(err)
+	}
+	token, err := store.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" || !token.Expiry.Equal(expiry) {
+		t.Fatalf("unexpected token %+v", token)
+	}
+}
+
+type staticTokenSource []*oauth2.Token
+
+func (s *staticTokenSource) Token() (*oauth2.Token, error) {
+	t := (*s)[0]
+	if len(*s) > 1 {
+		*s = (*s)[1:]
+	}
+	return t, nil
+}
+
+type memoryTokenStore struct {
+	writes []*oauth2.Token
+}
+
+func (s *memoryTokenStore) Token() (*oauth2.Token, error) {
+	if len(s.writes) == 0 {
+		return nil, ErrNoToken
+	}
+	return s.writes[len(s.writes)-1], nil
+}
+
+func (s *memoryTokenStore) SetToken(t *oauth2.Token) error {
+	s.writes = append(s.writes, t)
+	return nil
+}
+
+func TestStoringTokenSource(t *testing.T) {
+	t.Parallel()
+
+	initial := &oauth2.Token{AccessToken: "1"}
+	refreshed := &oauth2.Token{AccessToken: "2"}
+	store := &memoryTokenStore{}
+	ts := &storingTokenSource{
+		base:  &staticTokenSource{initial, initial, refreshed, refreshed},
+		store: store,
+		last:  initial,
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := ts.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(store.writes) != 1 || store.writes[0] != refreshed {
+		t.Fatalf("expected only the refreshed token to be stored, got %v", store.writes)
+	}
+}
@@ -0,0 +1,160 @@
+package mykubota
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrSkipSubtree can be returned from a Walk callback to skip the children of the current node
+var ErrSkipSubtree = errors.New("skip subtree")
+
+var errStopWalk = errors.New("stop walk")
+
+func buildModelTree(cs []Category, ms []Model) []*CategoryNode {
+	categoryModels := map[int][]Model{}
+	for _, m := range ms {
+		categoryModels[m.CategoryID] = append(categoryModels[m.CategoryID], m)
+	}
+
+	nodes := make([]*CategoryNode, 0, len(cs))
+	categories := map[int]*CategoryNode{}
+	for _, c := range cs {
+		models := categoryModels[c.ID]
+		sort.SliceStable(models, func(i, j int) bool {
+			return models[i].Model < models[j].Model
+		})
+		node := &CategoryNode{
+			ID:            c.ID,
+			Name:          c.Name,
+			SubCategories: []*CategoryNode{},
+			Models:        models,
+			ParentID:      c.ParentID,
+		}
+		nodes = append(nodes, node)
+		categories[c.ID] = node
+	}
+
+	roots := []*CategoryNode{}
+	for _, node := range nodes {
+		var parent *CategoryNode
+		if node.ParentID != nil {
+			parent = categories[*node.ParentID]
+		}
+		// missing parents and links closing a cycle make the category a root, so it isn't lost
+		if parent == nil || parent.hasAncestor(node) {
+			roots = append(roots, node)
+			continue
+		}
+		node.parent = parent
+		parent.SubCategories = append(parent.SubCategories, node)
+	}
+
+	sortCategoryNodes(roots)
+	for _, node := range nodes {
+		sortCategoryNodes(node.SubCategories)
+	}
+	return roots
+}
+
+// hasAncestor reports whether ancestor is n itself or one of its parents
+func (n *CategoryNode) hasAncestor(ancestor *CategoryNode) bool {
+	for node := n; node != nil; node = node.parent {
+		if node == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func sortCategoryNodes(nodes []*CategoryNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// Parent returns the parent category, or nil for root categories
+func (n *CategoryNode) Parent() *CategoryNode {
+	return n.parent
+}
+
+// Orphaned reports whether the category references a parent which isn't part of the catalog,
+// or whose ancestors include the category itself. Such categories are roots of the tree
+func (n *CategoryNode) Orphaned() bool {
+	return n.ParentID != nil && n.parent == nil
+}
+
+// Walk visits the node and all of its descendants depth first, each node at most once.
+// Returning ErrSkipSubtree skips the children of the visited node, any other error stops the walk
+func (n *CategoryNode) Walk(fn func(node *CategoryNode) error) error {
+	return n.walk(fn, map[*CategoryNode]bool{})
+}
+
+func (n *CategoryNode) walk(fn func(node *CategoryNode) error, visited map[*CategoryNode]bool) error {
+	if visited[n] {
+		return nil
+	}
+	visited[n] = true
+	if err := fn(n); err != nil {
+		if errors.Is(err, ErrSkipSubtree) {
+			return nil
+		}
+		return err
+	}
+	for _, child := range n.SubCategories {
+		if err := child.walk(fn, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find returns the category with the given ID within this subtree, or nil
+func (n *CategoryNode) Find(id int) *CategoryNode {
+	var found *CategoryNode
+	n.Walk(func(node *CategoryNode) error {
+		if node.ID == id {
+			found = node
+			return errStopWalk
+		}
+		return nil
+	})
+	return found
+}
+
+// Path returns all categories from the root down to this node
+func (n *CategoryNode) Path() []*CategoryNode {
+	path := []*CategoryNode{}
+	for node := n; node != nil && !containsNode(path, node); node = node.parent {
+		path = append([]*CategoryNode{node}, path...)
+	}
+	return path
+}
+
+func containsNode(nodes []*CategoryNode, node *CategoryNode) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// AllModels returns the models of this category and all subcategories
+func (n *CategoryNode) AllModels() []Model {
+	models := []Model{}
+	n.Walk(func(node *CategoryNode) error {
+		models = append(models, node.Models...)
+		return nil
+	})
+	return models
+}
+
+// ModelCount returns the number of models in this category including all subcategories
+func (n *CategoryNode) ModelCount() int {
+	count := 0
+	n.Walk(func(node *CategoryNode) error {
+		count += len(node.Models)
+		return nil
+	})
+	return count
+}
@@ -0,0 +1,140 @@
+package mykubota
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestBuildModelTree(t *testing.T) {
+	t.Parallel()
+
+	categories := []Category{
+		{ID: 1, Name: "Construction"},
+		{ID: 12, Name: "Excavators", ParentID: intPtr(1)},
+		{ID: 11, Name: "Track Loaders", ParentID: intPtr(1)},
+		{ID: 2, Name: "Tractors"},
+		{ID: 21, Name: "Compact", ParentID: intPtr(2)},
+		{ID: 99, Name: "Orphan", ParentID: intPtr(42)},
+		{ID: 991, Name: "Orphan Child", ParentID: intPtr(99)},
+	}
+	models := []Model{
+		{Model: "SVL97-2", CategoryID: 11},
+		{Model: "KX057-4", CategoryID: 12},
+		{Model: "KX040-4", CategoryID: 12},
+		{Model: "LX2610", CategoryID: 21},
+	}
+
+	for i := 0; i < 10; i++ {
+		roots := buildModelTree(categories, models)
+		orphans := []int{}
+		for _, root := range roots {
+			if root.Orphaned() {
+				orphans = append(orphans, root.ID)
+			}
+		}
+		if diff := cmp.Diff([]int{99}, orphans); diff != "" {
+			t.Fatalf("expected category 99 to be an orphaned root\n%s", diff)
+		}
+
+		ids := []int{}
+		for _, root := range roots {
+			root.Walk(func(node *CategoryNode) error {
+				ids = append(ids, node.ID)
+				return nil
+			})
+		}
+		if diff := cmp.Diff([]int{1, 11, 12, 2, 21, 99, 991}, ids); diff != "" {
+			t.Fatalf("unexpected walk order\n%s", diff)
+		}
+
+		excavators := roots[0].Find(12)
+		if excavators == nil {
+			t.Fatal("expected to find excavators")
+		}
+		if got := excavators.Models[0].Model; got != "KX040-4" {
+			t.Fatalf("expected models to be sorted, got %q first", got)
+		}
+		path := []string{}
+		for _, node := range excavators.Path() {
+			path = append(path, node.Name)
+		}
+		if diff := cmp.Diff([]string{"Construction", "Excavators"}, path); diff != "" {
+			t.Fatalf("unexpected path\n%s", diff)
+		}
+		if got := roots[0].ModelCount(); got != 3 {
+			t.Fatalf("expected 3 construction models, got %d", got)
+		}
+		if got := len(roots[1].AllModels()); got != 1 {
+			t.Fatalf("expected 1 tractor model, got %d", got)
+		}
+		if roots[1].Find(12) != nil {
+			t.Fatal("expected find to be limited to the subtree")
+		}
+	}
+}
+
+func TestCategoryNode_WalkSkipSubtree(t *testing.T) {
+	t.Parallel()
+
+	roots := buildModelTree([]Category{
+		{ID: 1, Name: "Root"},
+		{ID: 2, Name: "Child", ParentID: intPtr(1)},
+		{ID: 3, Name: "Grandchild", ParentID: intPtr(2)},
+	}, nil)
+
+	visited := []int{}
+	roots[0].Walk(func(node *CategoryNode) error {
+		visited = append(visited, node.ID)
+		if node.ID == 2 {
+			return ErrSkipSubtree
+		}
+		return nil
+	})
+	if diff := cmp.Diff([]int{1, 2}, visited); diff != "" {
+		t.Fatalf("unexpected visits\n%s", diff)
+	}
+}
+
+func TestBuildModelTree_cycles(t *testing.T) {
+	t.Parallel()
+
+	roots := buildModelTree([]Category{
+		{ID: 1, Name: "Self", ParentID: intPtr(1)},
+		{ID: 2, Name: "A", ParentID: intPtr(3)},
+		{ID: 3, Name: "B", ParentID: intPtr(2)},
+		{ID: 4, Name: "C", ParentID: intPtr(2)},
+	}, nil)
+
+	ids := []int{}
+	for _, root := range roots {
+		if !root.Orphaned() {
+			t.Errorf("expected root %d to be orphaned", root.ID)
+		}
+		root.Walk(func(node *CategoryNode) error {
+			ids = append(ids, node.ID)
+			return nil
+		})
+	}
+	if diff := cmp.Diff([]int{1, 3, 2, 4}, ids); diff != "" {
+		t.Fatalf("expected cycles to be broken without losing categories\n%s", diff)
+	}
+	if got := len(roots[1].Find(4).Path()); got != 3 {
+		t.Fatalf("expected path of length 3, got %d", got)
+	}
+
+	// trees assembled by hand may still contain cycles
+	a, b := &CategoryNode{ID: 5}, &CategoryNode{ID: 6}
+	a.SubCategories, b.SubCategories = []*CategoryNode{b}, []*CategoryNode{a}
+	a.parent, b.parent = b, a
+	if got := a.ModelCount(); got != 0 {
+		t.Fatalf("expected walk to terminate, got %d models", got)
+	}
+	if got := len(a.Path()); got != 2 {
+		t.Fatalf("expected path to stop at the cycle, got %d nodes", got)
+	}
+}
@@ -0,0 +1,312 @@
+package mykubota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WarrantyStatus string
+
+const (
+	// WarrantyActive means the machine is within the terms of its warranty
+	WarrantyActive WarrantyStatus = "active"
+	// WarrantyExpired means the warranty ended, either by date or by engine hours
+	WarrantyExpired WarrantyStatus = "expired"
+	// WarrantyUnknown means the machine has a warranty document without structured terms,
+	// or the document could not be loaded
+	WarrantyUnknown WarrantyStatus = "unknown"
+	// WarrantyNone means the API doesn't reference a warranty document for the machine
+	WarrantyNone WarrantyStatus = "none"
+)
+
+// WarrantyTerms is the structured metadata of a warranty document.
+// Zero values mean the document doesn't restrict coverage in that regard.
+//
+// Experimental: the format of warranty documents isn't documented, and the mapped fields are a best guess.
+// Fields which aren't mapped are kept in Extra, and values of mapped fields which can't be interpreted
+// are kept in Invalid instead of failing to decode
+type WarrantyTerms struct {
+	Plan      string    `json:"plan"`
+	StartDate time.Time `json:"startDate"`
+	EndDate   time.Time `json:"endDate"`
+	// MaxHours are the engine hours after which coverage ends
+	MaxHours float64 `json:"maxHours"`
+
+	// Extra keeps fields the SDK doesn't map, so they survive re-encoding
+	Extra map[string]json.RawMessage `json:"-"`
+	// Invalid keeps the values of mapped fields which couldn't be interpreted by their original key.
+	// They are encoded in place of the zero values of those fields
+	Invalid map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON accepts dates with or without time, and hours as numbers or strings
+func (t *WarrantyTerms) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*t = WarrantyTerms{}
+	for key, value := range fields {
+		mapped, err := t.decodeField(key, value)
+		switch {
+		case !mapped:
+			if t.Extra == nil {
+				t.Extra = map[string]json.RawMessage{}
+			}
+			t.Extra[key] = value
+		case err != nil:
+			if t.Invalid == nil {
+				t.Invalid = map[string]json.RawMessage{}
+			}
+			t.Invalid[key] = value
+		}
+	}
+	return nil
+}
+
+// decodeField sets the field mapped to key, matched case-insensitively like encoding/json does.
+// It reports whether key is mapped, and why its value can't be interpreted
+func (t *WarrantyTerms) decodeField(key string, value json.RawMessage) (bool, error) {
+	var err error
+	switch strings.ToLower(key) {
+	case "plan":
+		err = json.Unmarshal(value, &t.Plan)
+	case "startdate":
+		t.StartDate, err = parseWarrantyDate(value, false)
+	case "enddate":
+		t.EndDate, err = parseWarrantyDate(value, true)
+	case "maxhours":
+		t.MaxHours, err = parseWarrantyHours(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// MarshalJSON includes fields kept in Extra and Invalid
+func (t WarrantyTerms) MarshalJSON() ([]byte, error) {
+	type terms WarrantyTerms
+	bs, err := marshalExtra(terms(t), t.Extra)
+	if err != nil || len(t.Invalid) == 0 {
+		return bs, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(bs, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range t.Invalid {
+		for name := range fields {
+			if strings.EqualFold(name, key) {
+				delete(fields, name)
+			}
+		}
+		fields[key] = value
+	}
+	return json.Marshal(fields)
+}
+
+// invalidKeys returns the sorted keys of Invalid
+func (t *WarrantyTerms) invalidKeys() []string {
+	keys := make([]string, 0, len(t.Invalid))
+	for key := range t.Invalid {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// invalid reports whether the value of the mapped field name couldn't be interpreted
+func (t *WarrantyTerms) invalid(name string) bool {
+	for key := range t.Invalid {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWarrantyDate parses value as date or timestamp. Dates are moved to the end of the day if endOfDay is set,
+// because coverage lasts until the end of the last day
+func parseWarrantyDate(value json.RawMessage, endOfDay bool) (time.Time, error) {
+	v := ""
+	if err := json.Unmarshal(value, &v); err != nil {
+		return time.Time{}, err
+	}
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.Parse("2006-01-02", v); err == nil {
+		if endOfDay {
+			d = d.Add(24*time.Hour - time.Nanosecond)
+		}
+		return d, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// parseWarrantyHours parses value as number, or as string containing a number
+func parseWarrantyHours(value json.RawMessage) (float64, error) {
+	hours := 0.0
+	if err := json.Unmarshal(value, &hours); err == nil {
+		return hours, nil
+	}
+	v := ""
+	if err := json.Unmarshal(value, &v); err != nil {
+		return 0, err
+	}
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.TrimSpace(v), 64)
+}
+
+// Status returns the coverage of a machine with hours engine hours at the given time.
+// It is unknown if the terms have no limits, or a limit couldn't be interpreted and the other one isn't exceeded
+func (t *WarrantyTerms) Status(at time.Time, hours float64) WarrantyStatus {
+	if !t.EndDate.IsZero() && at.After(t.EndDate) {
+		return WarrantyExpired
+	}
+	if t.MaxHours > 0 && hours >= t.MaxHours {
+		return WarrantyExpired
+	}
+	if t.invalid("endDate") || t.invalid("maxHours") || (t.EndDate.IsZero() && t.MaxHours == 0) {
+		return WarrantyUnknown
+	}
+	return WarrantyActive
+}
+
+// WarrantyCoverage describes the warranty of an owned machine
+type WarrantyCoverage struct {
+	EquipmentID string         `json:"equipmentId"`
+	Nickname    string         `json:"nickName"`
+	Model       string         `json:"model"`
+	PinOrSerial string         `json:"pinOrSerial"`
+	WarrantyURL string         `json:"warrantyUrl"`
+	Status      WarrantyStatus `json:"status"`
+	// Terms are only set if the warranty document is structured
+	Terms       *WarrantyTerms `json:"terms,omitempty"`
+	EngineHours float64        `json:"engineHours"`
+	// Err explains why the status is unknown, if the document could not be loaded or interpreted.
+	// It is encoded as error message
+	Err error `json:"-"`
+}
+
+// MarshalJSON includes Err as error message
+func (c WarrantyCoverage) MarshalJSON() ([]byte, error) {
+	type coverage WarrantyCoverage
+	v := struct {
+		coverage
+		Error string `json:"error,omitempty"`
+	}{coverage: coverage(c)}
+	if c.Err != nil {
+		v.Error = c.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// EngineHours returns the engine hours of the equipment, preferring telematics over hours entered by the user
+func (e *Equipment) EngineHours() float64 {
+	if e.HasTelematics && e.Telematics.CumulativeOperatingHours > 0 {
+		return e.Telematics.CumulativeOperatingHours
+	}
+	return e.UserEnteredEngineHours
+}
+
+// WarrantyCoverage reports the warranty coverage of all equipment of the account.
+// Warranty documents served as JSON are parsed into WarrantyTerms; other documents, e.g. PDFs,
+// result in WarrantyUnknown. Failing to load a document doesn't fail the report, see WarrantyCoverage.Err
+func (s *Session) WarrantyCoverage(ctx context.Context) ([]WarrantyCoverage, error) {
+	eqs, err := s.ListEquipment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	res := make([]WarrantyCoverage, 0, len(eqs))
+	for _, eq := range eqs {
+		coverage := WarrantyCoverage{
+			EquipmentID: eq.ID,
+			Nickname:    eq.Nickname,
+			Model:       eq.Model,
+			PinOrSerial: eq.PinOrSerial,
+			WarrantyURL: eq.WarrantyURL,
+			Status:      WarrantyNone,
+			EngineHours: eq.EngineHours(),
+		}
+		if eq.WarrantyURL != "" {
+			coverage.Status = WarrantyUnknown
+			coverage.Terms, coverage.Err = s.WarrantyTerms(ctx, eq.WarrantyURL)
+			if coverage.Terms != nil {
+				coverage.Status = coverage.Terms.Status(now, coverage.EngineHours)
+				if coverage.Status == WarrantyUnknown && len(coverage.Terms.Invalid) > 0 {
+					coverage.Err = fmt.Errorf("unable to interpret warranty document fields %s", strings.Join(coverage.Terms.invalidKeys(), ", "))
+				}
+			}
+		}
+		res = append(res, coverage)
+	}
+	return res, nil
+}
+
+// WarrantyTerms loads the warranty document at warrantyURL, and returns nil if it isn't structured.
+// The document format is experimental, see WarrantyTerms.
+// The session token is only sent if the document is served by the MyKubota API
+func (s *Session) WarrantyTerms(ctx context.Context, warrantyURL string) (*WarrantyTerms, error) {
+	req, err := http.NewRequest("GET", warrantyURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, */*;q=0.5")
+	var terms *WarrantyTerms
+	processor := func(resp *http.Response) error {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			return nil
+		}
+		t := WarrantyTerms{}
+		if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+			return fmt.Errorf("unable to parse warranty document: %w", err)
+		}
+		terms = &t
+		return nil
+	}
+
+	if s.sameHost(warrantyURL) {
+		if err := s.do(req.WithContext(ctx), []int{http.StatusOK}, processor); err != nil {
+			return nil, err
+		}
+		return terms, nil
+	}
+	resp, err := s.plain.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+	if err := processor(resp); err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+// sameHost reports whether rawURL points to the API endpoint of the session
+func (s *Session) sameHost(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	endpoint, err := url.Parse(s.endpoint)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, endpoint.Scheme) && strings.EqualFold(u.Host, endpoint.Host)
+}
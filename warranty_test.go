@@ -0,0 +1,58 @@
+package mykubota
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWarrantyTerms_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	terms := WarrantyTerms{}
+	err := json.Unmarshal([]byte(`{
+		"Plan": "Powertrain", "startDate": "2022-01-01T08:00:00Z", "endDate": "next year", "maxHours": "2000",
+		"coverage": ["engine", "transmission"]
+	}`), &terms)
+	if err != nil {
+		t.Fatalf("expected unexpected values to be tolerated, got %v", err)
+	}
+	expected := WarrantyTerms{
+		Plan:      "Powertrain",
+		StartDate: time.Date(2022, 1, 1, 8, 0, 0, 0, time.UTC),
+		MaxHours:  2000,
+		Extra: map[string]json.RawMessage{
+			"coverage": json.RawMessage(`["engine", "transmission"]`),
+		},
+		Invalid: map[string]json.RawMessage{
+			"endDate": json.RawMessage(`"next year"`),
+		},
+	}
+	if diff := cmp.Diff(expected, terms); diff != "" {
+		t.Fatalf("unexpected terms\n%s", diff)
+	}
+	at := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	if status := terms.Status(at, 2500); status != WarrantyExpired {
+		t.Fatalf("expected hours to expire the warranty, got %s", status)
+	}
+	if status := terms.Status(at, 100); status != WarrantyUnknown {
+		t.Fatalf("expected an unreadable end date to make the status unknown, got %s", status)
+	}
+	bs, err := json.Marshal(terms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"coverage":["engine","transmission"],"endDate":"next year","maxHours":2000,"plan":"Powertrain","startDate":"2022-01-01T08:00:00Z"}`; string(bs) != expected {
+		t.Fatalf("expected unreadable values to be encoded as received, got %s", bs)
+	}
+
+	if err := json.Unmarshal([]byte(`{"terms": "see pdf"}`), &terms); err != nil {
+		t.Fatal(err)
+	}
+	if status := terms.Status(time.Now(), 0); status != WarrantyUnknown || terms.Plan != "" {
+		t.Fatalf("expected document without known fields to be unknown, got %s %+v", status, terms)
+	}
+	assertRoundTrip(t, `{"plan": "Standard", "startDate": "0001-01-01T00:00:00Z", "endDate": "2022-12-31T23:59:59Z", "maxHours": 100, "dealer": {"name": "Kubota Vancouver"}}`, &WarrantyTerms{})
+}